@@ -2,19 +2,89 @@ package config
 
 import (
 	"flag"
+	"fmt"
+	"os"
+	"runtime"
 	"time"
 )
 
 // AppConfig holds the application configuration from command-line flags
 type AppConfig struct {
-	NumStars       int
+	GenerationFlags
+
+	NumStars     int
+	OutputFormat string
+	OutputDir    string
+	ConfigFile   string
+	Seed         int64
+	DryRun       bool
+	Workers      int
+	ShardIndex   int
+	ShardCount   int
+}
+
+// GenerationFlags holds the flags that decide what each star system
+// draws. Generation and explain share them, so that explain can regenerate
+// any star of a dataset with the same settings.
+type GenerationFlags struct {
 	PlanetsPerStar int
 	ExoPerStar     int
-	OutputFormat   string
-	OutputDir      string
-	ConfigFile     string
-	Seed           int64
-	DryRun         bool
+	PopulationFile string
+
+	// Transit light curves
+	LightCurves       bool
+	LightCurveCadence time.Duration
+	LightCurveDays    float64
+	LightCurveNoise   float64
+
+	// Radial-velocity observations
+	RVObservations int
+	RVDays         float64
+	RVError        float64
+	RVJitter       float64
+
+	// Molecular composition of planet atmospheres
+	Compositions bool
+
+	// Catalog-style designations
+	Designations bool
+}
+
+// register defines the generation flags on fs
+func (g *GenerationFlags) register(fs *flag.FlagSet) {
+	fs.IntVar(&g.PlanetsPerStar, "planets-per-star", 8, "Maximum planets per star")
+	fs.IntVar(&g.ExoPerStar, "exo-per-star", 5, "Maximum exoplanets per star")
+	fs.StringVar(&g.PopulationFile, "population", "", "YAML population model file (built-in defaults if empty)")
+	fs.BoolVar(&g.LightCurves, "light-curves", false, "Generate light curves for transiting exoplanets")
+	fs.DurationVar(&g.LightCurveCadence, "light-curve-cadence", 30*time.Minute, "Time between light curve points")
+	fs.Float64Var(&g.LightCurveDays, "light-curve-days", 27, "Length of each light curve in days")
+	fs.Float64Var(&g.LightCurveNoise, "light-curve-noise", 200, "Light curve flux noise in ppm")
+	fs.IntVar(&g.RVObservations, "rv-observations", 0, "Radial-velocity observations per host star (0 disables)")
+	fs.Float64Var(&g.RVDays, "rv-days", 1095, "Time span of the radial-velocity observations in days")
+	fs.Float64Var(&g.RVError, "rv-error", 1.0, "Radial-velocity instrumental error in m/s")
+	fs.Float64Var(&g.RVJitter, "rv-jitter", 2.0, "Radial-velocity stellar jitter in m/s")
+	fs.BoolVar(&g.Compositions, "compositions", false, "Generate the molecular composition of planet atmospheres")
+	fs.BoolVar(&g.Designations, "designations", false, "Name stars and planets after catalog designations")
+}
+
+// validate caps the planet limits and checks the output settings
+func (g *GenerationFlags) validate() error {
+	if g.PlanetsPerStar > 15 {
+		g.PlanetsPerStar = 15
+	}
+	if g.ExoPerStar > 8 {
+		g.ExoPerStar = 8
+	}
+	if g.LightCurves && (g.LightCurveCadence <= 0 || g.LightCurveDays <= 0) {
+		return fmt.Errorf("--light-curve-cadence and --light-curve-days must be positive")
+	}
+	if g.LightCurveNoise < 0 {
+		return fmt.Errorf("--light-curve-noise must not be negative")
+	}
+	if g.RVObservations < 0 || g.RVDays <= 0 || g.RVError < 0 || g.RVJitter < 0 {
+		return fmt.Errorf("--rv-observations, --rv-error and --rv-jitter must not be negative and --rv-days must be positive")
+	}
+	return nil
 }
 
 // ParseFlags parses command-line flags and returns an AppConfig
@@ -22,16 +92,29 @@ func ParseFlags() *AppConfig {
 	cfg := &AppConfig{}
 
 	flag.IntVar(&cfg.NumStars, "num-stars", 100, "Number of stars to generate")
-	flag.IntVar(&cfg.PlanetsPerStar, "planets-per-star", 8, "Maximum planets per star")
-	flag.IntVar(&cfg.ExoPerStar, "exo-per-star", 5, "Maximum exoplanets per star")
 	flag.StringVar(&cfg.OutputFormat, "output-format", "csv", "Output format: csv, json, parquet, cassandra")
 	flag.StringVar(&cfg.OutputDir, "output-dir", "output", "Output directory")
 	flag.StringVar(&cfg.ConfigFile, "config", "", "YAML config file for Cassandra")
 	flag.Int64Var(&cfg.Seed, "seed", 0, "Random seed (0 for time-based)")
 	flag.BoolVar(&cfg.DryRun, "dry-run", false, "Dry run mode (no output)")
+	flag.IntVar(&cfg.Workers, "workers", runtime.NumCPU(), "Number of parallel generator workers")
+	flag.IntVar(&cfg.ShardIndex, "shard-index", 0, "0-based shard of the dataset to generate")
+	flag.IntVar(&cfg.ShardCount, "shard-count", 1, "Number of shards the dataset is split into")
+	cfg.GenerationFlags.register(flag.CommandLine)
 
 	flag.Parse()
 
+	// Shards of one dataset must agree on the seed
+	if cfg.ShardCount < 1 {
+		cfg.ShardCount = 1
+	}
+	if cfg.ShardIndex < 0 || cfg.ShardIndex >= cfg.ShardCount {
+		usageError(fmt.Sprintf("--shard-index must be between 0 and %d", cfg.ShardCount-1))
+	}
+	if cfg.ShardCount > 1 && cfg.Seed == 0 {
+		usageError("--seed is required when --shard-count is greater than 1")
+	}
+
 	// Use time-based seed if seed is 0
 	if cfg.Seed == 0 {
 		cfg.Seed = time.Now().UnixNano()
@@ -41,12 +124,145 @@ func ParseFlags() *AppConfig {
 	if cfg.NumStars < 1 {
 		cfg.NumStars = 1
 	}
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if err := cfg.GenerationFlags.validate(); err != nil {
+		usageError(err.Error())
+	}
+
+	return cfg
+}
+
+// usageError reports an invalid flag combination the same way the flag
+// package reports unknown flags
+func usageError(msg string) {
+	fmt.Fprintln(flag.CommandLine.Output(), msg)
+	flag.Usage()
+	os.Exit(2)
+}
+
+// ExplainConfig holds the configuration for the explain subcommand
+type ExplainConfig struct {
+	GenerationFlags
+
+	Seed  int64
+	Index int
+}
+
+// ParseExplainFlags parses the flags of the explain subcommand.
+// The generation flags must match the run being explained, since they
+// affect what each system draws.
+func ParseExplainFlags(args []string) (*ExplainConfig, error) {
+	cfg := &ExplainConfig{}
+
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	fs.Int64Var(&cfg.Seed, "seed", 0, "Random seed of the dataset (required)")
+	fs.IntVar(&cfg.Index, "index", 0, "0-based index of the star to regenerate (Star-<index+1>)")
+	cfg.GenerationFlags.register(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Validate configuration
+	if cfg.Seed == 0 {
+		return nil, fmt.Errorf("--seed is required")
+	}
+	if cfg.Index < 0 {
+		return nil, fmt.Errorf("--index must not be negative")
+	}
+	if err := cfg.GenerationFlags.validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// EphemerisConfig holds the configuration for the ephemeris subcommand
+type EphemerisConfig struct {
+	NumStars       int
+	PlanetsPerStar int
+	ExoPerStar     int
+	Seed           int64
+	Workers        int
+	PopulationFile string
+	Start          time.Time
+	End            time.Time
+	Cadence        time.Duration
+	OutputFormat   string
+	OutputDir      string
+	ConfigFile     string
+}
+
+// ephemerisTimeLayouts are the accepted formats of --start and --end
+var ephemerisTimeLayouts = []string{time.RFC3339, "2006-01-02"}
+
+// ParseEphemerisFlags parses the flags of the ephemeris subcommand.
+// The dataset flags must match the run whose planets are followed.
+func ParseEphemerisFlags(args []string) (*EphemerisConfig, error) {
+	cfg := &EphemerisConfig{}
+	var start, end string
+
+	fs := flag.NewFlagSet("ephemeris", flag.ExitOnError)
+	fs.IntVar(&cfg.NumStars, "num-stars", 100, "Number of stars of the dataset")
+	fs.IntVar(&cfg.PlanetsPerStar, "planets-per-star", 8, "Maximum planets per star used for the dataset")
+	fs.IntVar(&cfg.ExoPerStar, "exo-per-star", 5, "Maximum exoplanets per star used for the dataset")
+	fs.Int64Var(&cfg.Seed, "seed", 0, "Random seed of the dataset (required)")
+	fs.IntVar(&cfg.Workers, "workers", runtime.NumCPU(), "Number of parallel generator workers")
+	fs.StringVar(&cfg.PopulationFile, "population", "", "YAML population model file used for the dataset")
+	fs.StringVar(&start, "start", "2024-01-01", "Start of the time range (YYYY-MM-DD or RFC 3339)")
+	fs.StringVar(&end, "end", "2024-12-31", "End of the time range, inclusive (YYYY-MM-DD or RFC 3339)")
+	fs.DurationVar(&cfg.Cadence, "cadence", 24*time.Hour, "Time between points (e.g. 1h, 30m)")
+	fs.StringVar(&cfg.OutputFormat, "output-format", "csv", "Output format: csv, json, parquet, cassandra")
+	fs.StringVar(&cfg.OutputDir, "output-dir", "output", "Output directory")
+	fs.StringVar(&cfg.ConfigFile, "config", "", "YAML config file for Cassandra")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Validate configuration
+	var err error
+	if cfg.Start, err = parseEphemerisTime(start); err != nil {
+		return nil, fmt.Errorf("invalid --start: %w", err)
+	}
+	if cfg.End, err = parseEphemerisTime(end); err != nil {
+		return nil, fmt.Errorf("invalid --end: %w", err)
+	}
+	if cfg.End.Before(cfg.Start) {
+		return nil, fmt.Errorf("--end must not be before --start")
+	}
+	if cfg.Cadence <= 0 {
+		return nil, fmt.Errorf("--cadence must be positive")
+	}
+	if cfg.Seed == 0 {
+		return nil, fmt.Errorf("--seed is required")
+	}
+	if cfg.NumStars < 1 {
+		cfg.NumStars = 1
+	}
 	if cfg.PlanetsPerStar > 15 {
 		cfg.PlanetsPerStar = 15
 	}
 	if cfg.ExoPerStar > 8 {
 		cfg.ExoPerStar = 8
 	}
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
 
-	return cfg
+	return cfg, nil
+}
+
+// parseEphemerisTime parses a date or RFC 3339 timestamp as UTC
+func parseEphemerisTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range ephemerisTimeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, err
 }
@@ -0,0 +1,423 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PopulationConfig holds the stellar population model settings
+// This structure maps directly to the YAML population file
+type PopulationConfig struct {
+	// LuminosityFractions is the relative population of each luminosity class
+	// Valid keys: V, IV, III, II, I (stars), D (white dwarfs), L, T, Y (brown dwarfs)
+	// Classes that are omitted are not generated
+	LuminosityFractions map[string]float64 `yaml:"luminosity_fractions,omitempty"`
+
+	// StarParameters selects how star mass, radius and temperature relate
+	StarParameters StarParametersConfig `yaml:"star_parameters,omitempty"`
+
+	// IMF samples main-sequence star masses from an initial mass function
+	IMF *IMFConfig `yaml:"imf,omitempty"`
+
+	// Spatial selects how stars are distributed around the Sun
+	Spatial SpatialConfig `yaml:"spatial,omitempty"`
+
+	// Orbits controls how the orbits within a system are laid out
+	Orbits OrbitsConfig `yaml:"orbits,omitempty"`
+
+	// Occurrence draws planet counts and kinds from occurrence rates that
+	// depend on the host star
+	Occurrence *OccurrenceConfig `yaml:"occurrence,omitempty"`
+
+	// Multiplicity gives stars binary and triple companions
+	Multiplicity *MultiplicityConfig `yaml:"multiplicity,omitempty"`
+
+	// Architectures lays out planetary systems from weighted templates
+	Architectures *ArchitecturesConfig `yaml:"architectures,omitempty"`
+
+	// Climate controls how planet surface temperatures are derived
+	Climate ClimateConfig `yaml:"climate,omitempty"`
+
+	// Surveys applies survey sensitivity models, so that only detected
+	// exoplanets form the observed catalog
+	Surveys *SurveysConfig `yaml:"surveys,omitempty"`
+}
+
+// SurveysConfig holds the survey sensitivity models
+// Omitted parameters take the defaults
+type SurveysConfig struct {
+	Transit struct {
+		Noise        float64 `yaml:"noise,omitempty"`         // ppm over one hour (default 100)
+		BaselineDays float64 `yaml:"baseline_days,omitempty"` // Default: 1460
+		MinSNR       float64 `yaml:"min_snr,omitempty"`       // Default: 7.1
+	} `yaml:"transit,omitempty"`
+
+	RadialVelocity struct {
+		Precision    float64 `yaml:"precision,omitempty"`    // m/s per observation (default 1)
+		Observations int     `yaml:"observations,omitempty"` // Default: 50
+		MinSNR       float64 `yaml:"min_snr,omitempty"`      // Default: 10
+	} `yaml:"radial_velocity,omitempty"`
+
+	Imaging struct {
+		Contrast   float64 `yaml:"contrast,omitempty"`    // Planet/star flux ratio (default 1e-6)
+		InnerAngle float64 `yaml:"inner_angle,omitempty"` // arcsec (default 0.2)
+	} `yaml:"imaging,omitempty"`
+}
+
+// OccurrenceConfig holds the planet occurrence rate settings
+type OccurrenceConfig struct {
+	// Rates is the mean number of planets of each kind per star at solar
+	// metallicity, per spectral class (O, B, A, F, G, K, M, D, L, T, Y).
+	// Classes that are omitted have no planets; omitting rates uses the
+	// built-in defaults.
+	Rates map[string]PlanetKindsConfig `yaml:"rates,omitempty"`
+
+	// MetallicityExponents scale each rate by 10^(exponent * [Fe/H]).
+	// Omitted uses the built-in defaults (giant: 2).
+	MetallicityExponents *PlanetKindsConfig `yaml:"metallicity_exponents,omitempty"`
+}
+
+// PlanetKindsConfig holds one value for each kind of planet
+type PlanetKindsConfig struct {
+	Rocky float64 `yaml:"rocky,omitempty"` // Up to 5 Earth masses
+	Ice   float64 `yaml:"ice,omitempty"`   // 5-20 Earth masses
+	Giant float64 `yaml:"giant,omitempty"` // Above 20 Earth masses
+}
+
+// MultiplicityConfig holds the multiple star system settings
+type MultiplicityConfig struct {
+	// Fractions is the fraction of primaries with companions per spectral
+	// class (O, B, A, F, G, K, M, D, L, T, Y). Classes that are omitted
+	// have no companions; omitting fractions uses the built-in defaults.
+	Fractions map[string]float64 `yaml:"fractions,omitempty"`
+
+	// TripleFraction is the fraction of multiple systems with a third star
+	TripleFraction float64 `yaml:"triple_fraction,omitempty"`
+}
+
+// ArchitecturesConfig holds the planetary system templates
+type ArchitecturesConfig struct {
+	// Templates are chosen for each star by weight. A template without
+	// planets must name a built-in one (independent, hot-jupiter,
+	// compact-multi, solar-analogue, debris-disk) and reweights it.
+	// Templates that are omitted are not used; omitting templates uses the
+	// built-in ones with their default weights.
+	Templates []ArchitectureConfig `yaml:"templates,omitempty"`
+}
+
+// ArchitectureConfig holds one planetary system template
+type ArchitectureConfig struct {
+	Name    string              `yaml:"name"`
+	Weight  float64             `yaml:"weight"`
+	Planets []PlanetGroupConfig `yaml:"planets,omitempty"`
+}
+
+// PlanetGroupConfig holds a group of planets of one kind within a template
+type PlanetGroupConfig struct {
+	Kind         string     `yaml:"kind"`                   // rocky, ice or giant
+	Count        [2]int     `yaml:"count"`                  // Min, max number of planets
+	Axis         [2]float64 `yaml:"axis"`                   // Min, max semi-major axis in AU
+	Eccentricity [2]float64 `yaml:"eccentricity,omitempty"` // Min, max (default circular)
+
+	// PeriodRatios make the group a resonant chain with these period
+	// ratios between neighbouring planets
+	PeriodRatios []float64 `yaml:"period_ratios,omitempty"`
+}
+
+// ClimateConfig holds the planet climate settings
+type ClimateConfig struct {
+	// Greenhouse adds a greenhouse warming offset for the planet's
+	// atmosphere to the equilibrium temperature of solar system planets
+	Greenhouse bool `yaml:"greenhouse,omitempty"`
+}
+
+// OrbitsConfig holds the orbital architecture settings
+type OrbitsConfig struct {
+	// Spacing is "hill" (mutual Hill radii) or "period-ratio" to build
+	// stable systems; omitted draws every orbit independently
+	Spacing string `yaml:"spacing,omitempty"`
+}
+
+// SpatialConfig holds the spatial model settings
+type SpatialConfig struct {
+	// Model is "uniform" (uniform sphere, the default), "thin-disk" or "thick-disk"
+	Model string `yaml:"model,omitempty"`
+	// MaxDistance is the radius of the generated volume in parsecs (default 3000)
+	MaxDistance float64 `yaml:"max_distance,omitempty"`
+}
+
+// StarParametersConfig holds the star parameter model settings
+type StarParametersConfig struct {
+	// Model is "uniform" (independent ranges per class, the default) or
+	// "mass" (radius, temperature and subclass derived from mass)
+	Model string `yaml:"model,omitempty"`
+	// Scatter is the fractional scatter around the mass relations
+	Scatter float64 `yaml:"scatter,omitempty"`
+}
+
+// IMFConfig holds the initial mass function settings
+// Omitted parameters take the published values of the model
+type IMFConfig struct {
+	Model   string  `yaml:"model"`              // salpeter, kroupa or chabrier
+	MinMass float64 `yaml:"min_mass,omitempty"` // Default: 0.08 solar masses
+	MaxMass float64 `yaml:"max_mass,omitempty"` // Default: 100 solar masses
+
+	// Slopes are the power-law exponents alpha of dN/dM ∝ M^-alpha
+	// salpeter: [alpha], kroupa: [below 0.08, 0.08-0.5, above 0.5], chabrier: [above 1]
+	Slopes []float64 `yaml:"slopes,omitempty"`
+
+	// Chabrier log-normal parameters (characteristic mass and width in dex)
+	CharacteristicMass float64 `yaml:"characteristic_mass,omitempty"`
+	Sigma              float64 `yaml:"sigma,omitempty"`
+}
+
+// imfSlopeCounts is the number of slopes each IMF model takes
+var imfSlopeCounts = map[string]int{
+	"salpeter": 1,
+	"kroupa":   3,
+	"chabrier": 1,
+}
+
+// validLuminosityClasses lists the luminosity classes known to the generator
+var validLuminosityClasses = map[string]bool{
+	"V":   true,
+	"IV":  true,
+	"III": true,
+	"II":  true,
+	"I":   true,
+	"D":   true,
+	"L":   true,
+	"T":   true,
+	"Y":   true,
+}
+
+// validSpectralClasses lists the spectral class letters that start a
+// generated spectral type
+var validSpectralClasses = map[string]bool{
+	"O": true,
+	"B": true,
+	"A": true,
+	"F": true,
+	"G": true,
+	"K": true,
+	"M": true,
+	"D": true,
+	"L": true,
+	"T": true,
+	"Y": true,
+}
+
+// builtinArchitectures lists the architecture templates known to the
+// generator
+var builtinArchitectures = map[string]bool{
+	"independent":    true,
+	"hot-jupiter":    true,
+	"compact-multi":  true,
+	"solar-analogue": true,
+	"debris-disk":    true,
+}
+
+// validPlanetKinds lists the planet kinds of architecture planet groups
+var validPlanetKinds = map[string]bool{
+	"rocky": true,
+	"ice":   true,
+	"giant": true,
+}
+
+// LoadPopulationConfig loads the population model from a YAML file
+func LoadPopulationConfig(filename string) (*PopulationConfig, error) {
+	// Read the file
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read population file: %w", err)
+	}
+
+	// Parse YAML
+	var cfg PopulationConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML population file: %w", err)
+	}
+
+	// Validate configuration
+	if err := validatePopulationConfig(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid population: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// validatePopulationConfig validates the population model
+func validatePopulationConfig(cfg *PopulationConfig) error {
+	// Validate luminosity fractions
+	if cfg.LuminosityFractions != nil {
+		total := 0.0
+		for class, fraction := range cfg.LuminosityFractions {
+			if !validLuminosityClasses[class] {
+				return fmt.Errorf("unknown luminosity class '%s'", class)
+			}
+			if fraction < 0 {
+				return fmt.Errorf("fraction for luminosity class '%s' must not be negative", class)
+			}
+			total += fraction
+		}
+		if total <= 0 {
+			return fmt.Errorf("luminosity_fractions must contain at least one positive fraction")
+		}
+	}
+
+	// Validate star parameter model
+	switch cfg.StarParameters.Model {
+	case "", "uniform", "mass":
+	default:
+		return fmt.Errorf("unknown star_parameters model '%s' (valid: uniform, mass)", cfg.StarParameters.Model)
+	}
+	if cfg.StarParameters.Scatter < 0 {
+		return fmt.Errorf("star_parameters scatter must not be negative")
+	}
+
+	// Validate spatial model
+	switch cfg.Spatial.Model {
+	case "", "uniform", "thin-disk", "thick-disk":
+	default:
+		return fmt.Errorf("unknown spatial model '%s' (valid: uniform, thin-disk, thick-disk)", cfg.Spatial.Model)
+	}
+	if cfg.Spatial.MaxDistance < 0 {
+		return fmt.Errorf("spatial max_distance must not be negative")
+	}
+
+	// Validate orbit spacing
+	switch cfg.Orbits.Spacing {
+	case "", "hill", "period-ratio":
+	default:
+		return fmt.Errorf("unknown orbits spacing '%s' (valid: hill, period-ratio)", cfg.Orbits.Spacing)
+	}
+
+	// Validate occurrence rates
+	if o := cfg.Occurrence; o != nil {
+		for class, rate := range o.Rates {
+			if !validSpectralClasses[class] {
+				return fmt.Errorf("unknown spectral class '%s' in occurrence rates", class)
+			}
+			if rate.Rocky < 0 || rate.Ice < 0 || rate.Giant < 0 {
+				return fmt.Errorf("occurrence rates for class '%s' must not be negative", class)
+			}
+		}
+	}
+
+	// Validate multiplicity
+	if m := cfg.Multiplicity; m != nil {
+		for class, fraction := range m.Fractions {
+			if !validSpectralClasses[class] {
+				return fmt.Errorf("unknown spectral class '%s' in multiplicity fractions", class)
+			}
+			if fraction < 0 || fraction > 1 {
+				return fmt.Errorf("multiplicity fraction for class '%s' must be between 0 and 1", class)
+			}
+		}
+		if m.TripleFraction < 0 || m.TripleFraction > 1 {
+			return fmt.Errorf("multiplicity triple_fraction must be between 0 and 1")
+		}
+	}
+
+	// Validate architecture templates
+	if a := cfg.Architectures; a != nil {
+		if err := validateArchitectures(a.Templates); err != nil {
+			return err
+		}
+	}
+
+	// Validate survey models
+	if s := cfg.Surveys; s != nil {
+		if s.Transit.Noise < 0 || s.Transit.BaselineDays < 0 || s.Transit.MinSNR < 0 ||
+			s.RadialVelocity.Precision < 0 || s.RadialVelocity.Observations < 0 || s.RadialVelocity.MinSNR < 0 ||
+			s.Imaging.Contrast < 0 || s.Imaging.InnerAngle < 0 {
+			return fmt.Errorf("surveys parameters must not be negative")
+		}
+	}
+
+	// Validate IMF
+	if imf := cfg.IMF; imf != nil {
+		slopes, ok := imfSlopeCounts[imf.Model]
+		if !ok {
+			return fmt.Errorf("unknown imf model '%s' (valid: salpeter, kroupa, chabrier)", imf.Model)
+		}
+		if imf.Slopes != nil && len(imf.Slopes) != slopes {
+			return fmt.Errorf("imf model '%s' takes %d slopes, got %d", imf.Model, slopes, len(imf.Slopes))
+		}
+		if imf.MinMass < 0 || imf.MaxMass < 0 {
+			return fmt.Errorf("imf mass bounds must not be negative")
+		}
+		minMass, maxMass := imf.MinMass, imf.MaxMass
+		if minMass == 0 {
+			minMass = 0.08
+		}
+		if maxMass == 0 {
+			maxMass = 100
+		}
+		if minMass >= maxMass {
+			return fmt.Errorf("imf min_mass (%g) must be less than max_mass (%g)", minMass, maxMass)
+		}
+		if imf.CharacteristicMass < 0 || imf.Sigma < 0 {
+			return fmt.Errorf("imf characteristic_mass and sigma must not be negative")
+		}
+	}
+
+	return nil
+}
+
+// validateArchitectures validates the architecture templates
+func validateArchitectures(templates []ArchitectureConfig) error {
+	if templates == nil {
+		return nil
+	}
+
+	names := make(map[string]bool, len(templates))
+	total := 0.0
+	for _, t := range templates {
+		if t.Name == "" {
+			return fmt.Errorf("architecture templates must have a name")
+		}
+		if names[t.Name] {
+			return fmt.Errorf("duplicate architecture template '%s'", t.Name)
+		}
+		names[t.Name] = true
+
+		if t.Weight < 0 {
+			return fmt.Errorf("weight of architecture template '%s' must not be negative", t.Name)
+		}
+		total += t.Weight
+
+		if len(t.Planets) == 0 && !builtinArchitectures[t.Name] {
+			return fmt.Errorf("architecture template '%s' has no planets and is not built in", t.Name)
+		}
+		if len(t.Planets) > 0 && t.Name == "independent" {
+			return fmt.Errorf("architecture template 'independent' cannot have planets")
+		}
+		for _, g := range t.Planets {
+			if !validPlanetKinds[g.Kind] {
+				return fmt.Errorf("unknown planet kind '%s' in architecture template '%s' (valid: rocky, ice, giant)", g.Kind, t.Name)
+			}
+			if g.Count[0] < 0 || g.Count[0] > g.Count[1] {
+				return fmt.Errorf("planet count of architecture template '%s' must be a range [min, max] with 0 <= min <= max", t.Name)
+			}
+			if g.Axis[0] <= 0 || g.Axis[0] > g.Axis[1] {
+				return fmt.Errorf("planet axis of architecture template '%s' must be a range [min, max] with 0 < min <= max", t.Name)
+			}
+			if g.Eccentricity[0] < 0 || g.Eccentricity[0] > g.Eccentricity[1] || g.Eccentricity[1] >= 1 {
+				return fmt.Errorf("planet eccentricity of architecture template '%s' must be a range [min, max] with 0 <= min <= max < 1", t.Name)
+			}
+			for _, ratio := range g.PeriodRatios {
+				if ratio <= 1 {
+					return fmt.Errorf("period ratios of architecture template '%s' must be greater than 1", t.Name)
+				}
+			}
+		}
+	}
+	if total <= 0 {
+		return fmt.Errorf("architecture templates must contain at least one positive weight")
+	}
+
+	return nil
+}
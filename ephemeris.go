@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"djdees/synthetic_stellar_data/config"
+	"djdees/synthetic_stellar_data/generator"
+	"djdees/synthetic_stellar_data/models"
+	"djdees/synthetic_stellar_data/writers"
+)
+
+// runEphemeris regenerates a dataset and writes the positions of its
+// planets and exoplanets over a time range
+func runEphemeris(args []string) {
+	cfg, err := config.ParseEphemerisFlags(args)
+	if err != nil {
+		log.Fatalf("Invalid ephemeris arguments: %v", err)
+	}
+
+	fmt.Println("=== Stellargen: Ephemeris ===")
+	fmt.Printf("Number of Stars: %d\n", cfg.NumStars)
+	fmt.Printf("Seed: %d\n", cfg.Seed)
+	fmt.Printf("Time Range: %s to %s every %v\n",
+		cfg.Start.Format(time.RFC3339), cfg.End.Format(time.RFC3339), cfg.Cadence)
+	fmt.Printf("Output Format: %s\n", cfg.OutputFormat)
+	fmt.Println()
+
+	genCfg := generator.Config{
+		NumStars:       cfg.NumStars,
+		PlanetsPerStar: cfg.PlanetsPerStar,
+		ExoPerStar:     cfg.ExoPerStar,
+		Seed:           cfg.Seed,
+		Workers:        cfg.Workers,
+	}
+	if err := applyPopulation(&genCfg, cfg.PopulationFile); err != nil {
+		log.Fatalf("Failed to load population model: %v", err)
+	}
+
+	// Stop generation cleanly on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if cfg.OutputFormat != "cassandra" {
+		if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
+			log.Fatalf("Failed to create output directory: %v", err)
+		}
+	}
+
+	fmt.Printf("Generating ephemeris and writing output in %s format...\n", cfg.OutputFormat)
+	startTime := time.Now()
+	count := 0
+	points := countPoints(ctx, generator.Ephemeris(ctx, genCfg, generator.EphemerisRange{
+		Start:   cfg.Start,
+		End:     cfg.End,
+		Cadence: cfg.Cadence,
+	}), &count)
+
+	switch cfg.OutputFormat {
+	case "csv":
+		err = writers.WriteEphemerisCSV(ctx, points, cfg.OutputDir)
+	case "json":
+		err = writers.WriteEphemerisJSON(ctx, points, cfg.OutputDir)
+	case "parquet":
+		err = writers.WriteEphemerisParquet(ctx, points, cfg.OutputDir)
+	case "cassandra":
+		if cfg.ConfigFile == "" {
+			log.Fatal("Cassandra output requires --config flag with YAML configuration file")
+		}
+		err = writers.WriteEphemerisToCassandra(ctx, points, cfg.ConfigFile)
+	default:
+		log.Fatalf("Unsupported output format: %s", cfg.OutputFormat)
+	}
+	if err != nil {
+		log.Fatalf("Failed to write ephemeris: %v", err)
+	}
+
+	fmt.Printf("Generated %d ephemeris points\n", count)
+	fmt.Printf("Output written successfully in %v\n", time.Since(startTime))
+	fmt.Println("\nDone!")
+}
+
+// countPoints forwards points from in while counting them into count.
+// The count is complete once the returned channel has been drained.
+// Consumers that stop reading early must cancel ctx so the forwarding
+// goroutine can exit.
+func countPoints(ctx context.Context, in <-chan models.EphemerisPoint, count *int) <-chan models.EphemerisPoint {
+	out := make(chan models.EphemerisPoint)
+
+	go func() {
+		defer close(out)
+		for point := range in {
+			*count++
+			select {
+			case out <- point:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"djdees/synthetic_stellar_data/config"
+	"djdees/synthetic_stellar_data/generator"
+)
+
+// runExplain regenerates a single star system from a dataset seed and
+// prints it as JSON, without generating the stars before it
+func runExplain(args []string) {
+	cfg, err := config.ParseExplainFlags(args)
+	if err != nil {
+		log.Fatalf("Invalid explain arguments: %v", err)
+	}
+
+	genCfg, err := generatorConfig(cfg.GenerationFlags, cfg.Seed)
+	if err != nil {
+		log.Fatalf("Failed to load population model: %v", err)
+	}
+
+	system := generator.GenerateSystem(genCfg, cfg.Index)
+
+	out, err := json.MarshalIndent(system, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode system: %v", err)
+	}
+
+	fmt.Printf("# Seed %d, star index %d\n", cfg.Seed, cfg.Index)
+	fmt.Println(string(out))
+}
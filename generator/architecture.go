@@ -0,0 +1,199 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// Built-in architecture names
+const (
+	ArchitectureIndependent   = "independent"
+	ArchitectureHotJupiter    = "hot-jupiter"
+	ArchitectureCompactMulti  = "compact-multi"
+	ArchitectureSolarAnalogue = "solar-analogue"
+	ArchitectureDebrisDisk    = "debris-disk"
+)
+
+// Planet kinds of a PlanetGroup
+const (
+	PlanetKindRocky = "rocky"
+	PlanetKindIce   = "ice"
+	PlanetKindGiant = "giant"
+)
+
+// planetKinds maps the PlanetKind* names to kinds
+var planetKinds = map[string]int{
+	PlanetKindRocky: kindRocky,
+	PlanetKindIce:   kindIce,
+	PlanetKindGiant: kindGiant,
+}
+
+// Architecture is a template for the planets of a system, chosen for each
+// star in proportion to Weight
+type Architecture struct {
+	Name   string
+	Weight float64
+
+	// Independent draws the planets like systems without architectures,
+	// with independent orbits and kinds; Planets is not used
+	Independent bool
+
+	// Planets are the groups of planets the template places, in order. A
+	// template without groups has no planets.
+	Planets []PlanetGroup
+}
+
+// PlanetGroup is a number of planets of one kind within an orbit range
+type PlanetGroup struct {
+	Kind         string     // PlanetKindRocky, PlanetKindIce or PlanetKindGiant
+	Count        [2]int     // Min, Max number of planets
+	Axis         [2]float64 // Min, Max semi-major axis in AU
+	Eccentricity [2]float64 // Min, Max
+
+	// PeriodRatios, if set, make the group a resonant chain: the first
+	// planet orbits within Axis and every further planet just wide of one
+	// of these period ratios to its inner neighbour
+	PeriodRatios []float64
+}
+
+// DefaultArchitectures are the built-in templates and their weights. Hot
+// Jupiters are rare and lonely (Wright et al. 2012), compact chains follow
+// TRAPPIST-1 (Luger et al. 2017) and solar analogues have rocky planets
+// inside the snow line and giants beyond it.
+var DefaultArchitectures = []Architecture{
+	{Name: ArchitectureIndependent, Weight: 0.5, Independent: true},
+	{Name: ArchitectureHotJupiter, Weight: 0.05, Planets: []PlanetGroup{
+		{Kind: PlanetKindGiant, Count: [2]int{1, 1}, Axis: [2]float64{0.02, 0.1}, Eccentricity: [2]float64{0, 0.05}},
+	}},
+	{Name: ArchitectureCompactMulti, Weight: 0.15, Planets: []PlanetGroup{
+		{Kind: PlanetKindRocky, Count: [2]int{3, 7}, Axis: [2]float64{0.01, 0.05}, Eccentricity: [2]float64{0, 0.02},
+			PeriodRatios: []float64{4.0 / 3, 3.0 / 2, 8.0 / 5, 5.0 / 3, 2}},
+	}},
+	{Name: ArchitectureSolarAnalogue, Weight: 0.2, Planets: []PlanetGroup{
+		{Kind: PlanetKindRocky, Count: [2]int{2, 4}, Axis: [2]float64{0.3, 2}, Eccentricity: [2]float64{0, 0.1}},
+		{Kind: PlanetKindGiant, Count: [2]int{1, 2}, Axis: [2]float64{4, 10}, Eccentricity: [2]float64{0, 0.06}},
+		{Kind: PlanetKindIce, Count: [2]int{1, 2}, Axis: [2]float64{15, 35}, Eccentricity: [2]float64{0, 0.03}},
+	}},
+	{Name: ArchitectureDebrisDisk, Weight: 0.1},
+}
+
+// BuiltinArchitecture returns the built-in template with the given name
+func BuiltinArchitecture(name string) (Architecture, bool) {
+	for _, arch := range DefaultArchitectures {
+		if arch.Name == name {
+			return arch, true
+		}
+	}
+	return Architecture{}, false
+}
+
+// Planets in resonant chains lie up to 2% wide of exact resonance, like
+// the peak of near-resonant Kepler pairs (Fabrycky et al. 2014)
+const resonanceOffset = 0.02
+
+// kindAny draws the kind of a planetSlot from occurrence rates or fixed
+// fractions
+const kindAny = -1
+
+// planetSlot describes the orbit and kind of a planet to generate
+type planetSlot struct {
+	kind         int        // kind* constant or kindAny
+	rates        []float64  // Occurrence rates kindAny draws from, if any
+	axis         [2]float64 // Nominal semi-major axis range in AU
+	eccentricity [2]float64 // Min, Max
+}
+
+// Slots of planets and exoplanets drawn independently
+var (
+	independentPlanet    = planetSlot{kind: kindAny, axis: [2]float64{0.05, 50.0}, eccentricity: [2]float64{0.0, 0.3}}
+	independentExoplanet = planetSlot{kind: kindAny, axis: [2]float64{0.01, 5.0}, eccentricity: [2]float64{0.0, 0.5}}
+)
+
+// chooseArchitecture picks one of the architectures by weight, or returns
+// nil without architectures
+func chooseArchitecture(r *rand.Rand, architectures []Architecture) *Architecture {
+	if len(architectures) == 0 {
+		return nil
+	}
+
+	weights := make([]float64, len(architectures))
+	for i, arch := range architectures {
+		weights[i] = arch.Weight
+	}
+	return &architectures[weightedChoice(r, weights)]
+}
+
+// count draws the number of planets in the group
+func (g PlanetGroup) count(r *rand.Rand) int {
+	return g.Count[0] + r.Intn(g.Count[1]-g.Count[0]+1)
+}
+
+// slot returns the slot of every planet in the group
+func (g PlanetGroup) slot() planetSlot {
+	return planetSlot{kind: planetKinds[g.Kind], axis: g.Axis, eccentricity: g.Eccentricity}
+}
+
+// chainAxes returns the semi-major axes of a resonant chain of n planets
+// starting at first
+func (g PlanetGroup) chainAxes(r *rand.Rand, first float64, n int) []float64 {
+	axes := []float64{first}
+	for j := 1; j < n; j++ {
+		ratio := g.PeriodRatios[r.Intn(len(g.PeriodRatios))] * randFloat(r, 1, 1+resonanceOffset)
+		axes = append(axes, axes[j-1]*math.Pow(ratio, 2.0/3.0))
+	}
+	return axes
+}
+
+// architectureBodies lays out the bodies an architecture places around star
+// once, up to planetLimit planets and exoLimit exoplanets. Each body becomes
+// a planet or an exoplanet in proportion to the room left for each.
+func architectureBodies(r *rand.Rand, star models.Star, arch Architecture, planetLimit, exoLimit int) ([]models.Planet, []models.Exoplanet) {
+	var planets []models.Planet
+	var exoplanets []models.Exoplanet
+
+	// body refers to a planet, or with exoplanet set to an exoplanet
+	type body struct {
+		exoplanet bool
+		index     int
+	}
+	axis := func(b body) float64 {
+		if b.exoplanet {
+			return exoplanets[b.index].SemiMajorAxis
+		}
+		return planets[b.index].SemiMajorAxis
+	}
+	setAxis := func(b body, a float64) {
+		if b.exoplanet {
+			exoplanets[b.index].SemiMajorAxis = a
+			exoplanets[b.index].OrbitalPeriod = orbitalPeriod(star, a)
+		} else {
+			planets[b.index].SemiMajorAxis = a
+			planets[b.index].OrbitalPeriod = orbitalPeriod(star, a)
+		}
+	}
+
+	for _, g := range arch.Planets {
+		planetsLeft, exoLeft := planetLimit-len(planets), exoLimit-len(exoplanets)
+		bodies := make([]body, min(g.count(r), planetsLeft+exoLeft))
+		for j := range bodies {
+			if r.Intn(planetsLeft+exoLeft) < exoLeft {
+				bodies[j] = body{exoplanet: true, index: len(exoplanets)}
+				exoplanets = append(exoplanets, generateExoplanet(r, star, len(exoplanets)+1, g.slot()))
+				exoLeft--
+			} else {
+				bodies[j] = body{index: len(planets)}
+				planets = append(planets, generatePlanet(r, star, len(planets)+1, g.slot()))
+				planetsLeft--
+			}
+		}
+
+		if len(g.PeriodRatios) > 0 && len(bodies) > 1 {
+			for j, a := range g.chainAxes(r, axis(bodies[0]), len(bodies)) {
+				setAxis(bodies[j], a)
+			}
+		}
+	}
+	return planets, exoplanets
+}
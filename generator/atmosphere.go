@@ -0,0 +1,161 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// Atmosphere types
+const (
+	atmosphereHydrogen = "H2/He dominant"
+	atmosphereNitrogen = "N2/O2 dominant"
+	atmosphereCarbon   = "CO2 dominant"
+	atmosphereThin     = "Thin atmosphere"
+	atmosphereNone     = "No atmosphere"
+	atmosphereMethane  = "H2/He with methane"
+	atmosphereSulfuric = "Sulfuric compounds"
+	atmosphereSteam    = "Water vapor rich"
+)
+
+// earthEscapeVelocity is Earth's escape velocity in km/s
+const earthEscapeVelocity = 11.186
+
+// shorelineSlope is the equilibrium temperature in Kelvin per km/s of
+// escape velocity above which rocky planets lose their atmosphere. It is
+// the "cosmic shoreline" of Zahnle & Catling (2017), I ∝ v_esc^4, placed
+// so that Mars sits on it and Mercury and the Moon lie beyond it.
+const shorelineSlope = 42.0
+
+// Temperatures in Kelvin that separate the atmosphere regimes
+const (
+	methaneLimit   = 1000.0 // CH4 is the main carbon carrier of giants below this
+	runawayLimit   = 320.0  // Rocky planets above this lose their oceans
+	temperateLimit = 180.0  // Rocky planets below this freeze out
+)
+
+// atmosphereChoice is one possible atmosphere of a regime and its weight
+type atmosphereChoice struct {
+	name   string
+	weight float64
+}
+
+// Atmospheres of each regime, picked by weight
+var (
+	hotGiantAtmospheres  = []atmosphereChoice{{atmosphereHydrogen, 1}}
+	coolGiantAtmospheres = []atmosphereChoice{{atmosphereMethane, 1}}
+
+	hotSubNeptuneAtmospheres  = []atmosphereChoice{{atmosphereHydrogen, 0.5}, {atmosphereSteam, 0.5}}
+	warmSubNeptuneAtmospheres = []atmosphereChoice{{atmosphereHydrogen, 0.7}, {atmosphereSteam, 0.3}}
+	coolSubNeptuneAtmospheres = []atmosphereChoice{{atmosphereMethane, 0.6}, {atmosphereHydrogen, 0.2}, {atmosphereSteam, 0.2}}
+
+	hotRockyAtmospheres       = []atmosphereChoice{{atmosphereCarbon, 0.6}, {atmosphereSulfuric, 0.25}, {atmosphereSteam, 0.15}}
+	temperateRockyAtmospheres = []atmosphereChoice{{atmosphereNitrogen, 0.45}, {atmosphereCarbon, 0.3}, {atmosphereSteam, 0.15}, {atmosphereThin, 0.1}}
+	coldRockyAtmospheres      = []atmosphereChoice{{atmosphereCarbon, 0.4}, {atmosphereNitrogen, 0.3}, {atmosphereThin, 0.3}}
+)
+
+// escapeVelocity returns the escape velocity in km/s of a planet with the
+// given mass and radius in Earth units
+func escapeVelocity(mass, radius float64) float64 {
+	return earthEscapeVelocity * math.Sqrt(mass/radius)
+}
+
+// chooseAtmosphere picks the atmosphere of a planet from its class,
+// equilibrium temperature and escape velocity. Giants always keep their
+// hydrogen envelope; rocky planets hotter than the cosmic shoreline are
+// bare, and those close to it keep only a thin atmosphere.
+func chooseAtmosphere(r *rand.Rand, class string, eqTemp, vEsc float64) string {
+	var choices []atmosphereChoice
+	switch class {
+	case PlanetClassNeptune, PlanetClassJovian:
+		choices = coolGiantAtmospheres
+		if eqTemp >= methaneLimit {
+			choices = hotGiantAtmospheres
+		}
+	case PlanetClassSubNeptune:
+		switch {
+		case eqTemp >= methaneLimit:
+			choices = hotSubNeptuneAtmospheres
+		case eqTemp >= methaneLimit/2:
+			choices = warmSubNeptuneAtmospheres
+		default:
+			choices = coolSubNeptuneAtmospheres
+		}
+	default:
+		shoreline := eqTemp / (shorelineSlope * vEsc)
+		switch {
+		case shoreline > 1:
+			return atmosphereNone
+		case shoreline > 0.75:
+			return atmosphereThin
+		case eqTemp >= runawayLimit:
+			choices = hotRockyAtmospheres
+		case eqTemp >= temperateLimit:
+			choices = temperateRockyAtmospheres
+		default:
+			choices = coldRockyAtmospheres
+		}
+	}
+
+	weights := make([]float64, len(choices))
+	for i, c := range choices {
+		weights[i] = c.weight
+	}
+	return choices[weightedChoice(r, weights)].name
+}
+
+// setAtmospheres chooses the atmosphere of each planet and sets its surface
+// temperature. Equilibrium temperatures must already be set.
+func setAtmospheres(r *rand.Rand, cfg Config, planets []models.Planet) {
+	for i := range planets {
+		p := &planets[i]
+		p.Atmosphere = chooseAtmosphere(r, p.PlanetClass, p.EquilibriumTemp, escapeVelocity(p.Mass, p.Radius))
+		p.SurfaceTemp = surfaceTemperature(cfg, *p)
+	}
+}
+
+// gas is one molecule of an atmosphere and its mole fraction
+type gas struct {
+	molecule string
+	fraction float64
+}
+
+// atmosphereCompositions are the typical mole fractions of each atmosphere
+// type, after Jupiter, Earth, Venus, Mars and Io. Bare planets have none.
+var atmosphereCompositions = map[string][]gas{
+	atmosphereHydrogen: {{"H2", 0.85}, {"He", 0.145}, {"H2O", 0.004}, {"CO", 0.001}},
+	atmosphereMethane:  {{"H2", 0.86}, {"He", 0.136}, {"CH4", 0.003}, {"NH3", 0.001}},
+	atmosphereNitrogen: {{"N2", 0.78}, {"O2", 0.21}, {"Ar", 0.0093}, {"H2O", 0.0004}, {"CO2", 0.0004}},
+	atmosphereCarbon:   {{"CO2", 0.965}, {"N2", 0.035}, {"SO2", 0.00015}},
+	atmosphereThin:     {{"CO2", 0.95}, {"N2", 0.028}, {"Ar", 0.02}, {"O2", 0.002}},
+	atmosphereSulfuric: {{"SO2", 0.85}, {"CO2", 0.1}, {"H2SO4", 0.03}, {"N2", 0.02}},
+	atmosphereSteam:    {{"H2O", 0.7}, {"CO2", 0.2}, {"N2", 0.08}, {"H2", 0.02}},
+}
+
+// compositionScatter is the log-normal scatter of each mole fraction
+// around its typical value, before renormalisation
+const compositionScatter = 0.3
+
+// setCompositions draws the molecular composition of each planet's
+// atmosphere around the typical mole fractions of its type
+func setCompositions(r *rand.Rand, planets []models.Planet) {
+	for i := range planets {
+		gases := atmosphereCompositions[planets[i].Atmosphere]
+		if len(gases) == 0 {
+			continue
+		}
+
+		composition := make(map[string]float64, len(gases))
+		total := 0.0
+		for _, g := range gases {
+			fraction := g.fraction * math.Exp(r.NormFloat64()*compositionScatter)
+			composition[g.molecule] = fraction
+			total += fraction
+		}
+		for molecule := range composition {
+			composition[molecule] /= total
+		}
+		planets[i].Composition = composition
+	}
+}
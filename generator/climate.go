@@ -0,0 +1,135 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// bondAlbedoRanges are the Bond albedo ranges of each planet class, from
+// dark rocky surfaces to cloudy giants
+var bondAlbedoRanges = map[string][2]float64{
+	PlanetClassTerrestrial: {0.05, 0.45},
+	PlanetClassSuperEarth:  {0.05, 0.45},
+	PlanetClassSubNeptune:  {0.2, 0.4},
+	PlanetClassNeptune:     {0.25, 0.35},
+	PlanetClassJovian:      {0.3, 0.55},
+}
+
+// greenhouseOffsets is the warming in Kelvin of each atmosphere type above
+// the equilibrium temperature, applied with Config.Greenhouse. Giants have
+// no surface and are left at equilibrium.
+var greenhouseOffsets = map[string]float64{
+	atmosphereNitrogen: 33,  // Earth
+	atmosphereCarbon:   500, // Venus
+	atmosphereThin:     5,   // Mars
+	atmosphereSulfuric: 300,
+	atmosphereSteam:    100,
+}
+
+// bondAlbedo draws the Bond albedo of a planet of the given class
+func bondAlbedo(r *rand.Rand, class string) float64 {
+	albedo := bondAlbedoRanges[class]
+	return randFloat(r, albedo[0], albedo[1])
+}
+
+// habitableZoneLimit holds the Kopparapu et al. (2014) fit of the effective
+// stellar flux at one habitable-zone limit, S = S0 + aT + bT^2 + cT^3 + dT^4
+// with T = Teff - 5780 K, for an Earth-mass planet
+type habitableZoneLimit struct {
+	s0, a, b, c, d float64
+}
+
+// Habitable-zone limits
+var (
+	hzRecentVenus       = habitableZoneLimit{1.776, 2.136e-4, 2.533e-8, -1.332e-11, -3.097e-15}
+	hzRunawayGreenhouse = habitableZoneLimit{1.107, 1.332e-4, 1.580e-8, -8.308e-12, -1.931e-15}
+	hzMaximumGreenhouse = habitableZoneLimit{0.356, 6.171e-5, 1.698e-9, -3.198e-12, -5.575e-16}
+	hzEarlyMars         = habitableZoneLimit{0.320, 5.547e-5, 1.526e-9, -2.874e-12, -5.011e-16}
+)
+
+// Effective temperature range in Kelvin over which the fits are valid;
+// stars outside it use the nearest end
+const (
+	minHabitableZoneTemp = 2600.0
+	maxHabitableZoneTemp = 7200.0
+)
+
+// flux returns the effective stellar flux, relative to Earth's, at the limit
+// for a star of the given effective temperature
+func (l habitableZoneLimit) flux(temperature int32) float64 {
+	t := math.Max(minHabitableZoneTemp, math.Min(maxHabitableZoneTemp, float64(temperature))) - 5780
+	return l.s0 + l.a*t + l.b*t*t + l.c*t*t*t + l.d*t*t*t*t
+}
+
+// distance returns the distance in AU of the limit around star
+func (l habitableZoneLimit) distance(star models.Star) float64 {
+	return math.Sqrt(star.Luminosity / l.flux(star.Temperature))
+}
+
+// setHabitableZone sets the conservative and optimistic habitable-zone
+// bounds of star
+func setHabitableZone(star *models.Star) {
+	star.HZInner = hzRunawayGreenhouse.distance(*star)
+	star.HZOuter = hzMaximumGreenhouse.distance(*star)
+	star.HZOptimisticInner = hzRecentVenus.distance(*star)
+	star.HZOptimisticOuter = hzEarlyMars.distance(*star)
+}
+
+// insolation returns the orbit-averaged stellar flux, relative to Earth's,
+// on an orbit around a host of the given luminosity in solar units
+func insolation(luminosity, semiMajorAxis, eccentricity float64) float64 {
+	return luminosity / (semiMajorAxis * semiMajorAxis * math.Sqrt(1-eccentricity*eccentricity))
+}
+
+// equilibriumTemperature returns the orbit-averaged equilibrium temperature
+// in Kelvin of a planet with the given Bond albedo around a host of the
+// given luminosity in solar units, assuming full heat redistribution
+func equilibriumTemperature(luminosity, semiMajorAxis, eccentricity, albedo float64) float64 {
+	return solarTemperature * math.Pow(luminosity, 0.25) * math.Sqrt(solarRadiusAU/(2*semiMajorAxis)) *
+		math.Pow(1-albedo, 0.25) * math.Pow(1-eccentricity*eccentricity, -0.125)
+}
+
+// inHabitableZone reports whether a planet receiving the given insolation
+// lies within the conservative habitable zone of a host of the given
+// effective temperature
+func inHabitableZone(temperature int32, flux float64) bool {
+	return flux <= hzRunawayGreenhouse.flux(temperature) && flux >= hzMaximumGreenhouse.flux(temperature)
+}
+
+// setPlanetClimate sets the insolation, equilibrium temperature and
+// habitable-zone flag of planets from their final orbits around a host of
+// the given luminosity and effective temperature
+func setPlanetClimate(luminosity float64, temperature int32, planets []models.Planet) {
+	for i := range planets {
+		p := &planets[i]
+		p.Insolation = insolation(luminosity, p.SemiMajorAxis, p.Eccentricity)
+		p.EquilibriumTemp = equilibriumTemperature(luminosity, p.SemiMajorAxis, p.Eccentricity, p.BondAlbedo)
+		p.InHabitableZone = inHabitableZone(temperature, p.Insolation)
+	}
+}
+
+// surfaceTemperature returns the surface temperature of a planet in Kelvin:
+// its equilibrium temperature, plus the warming of its atmosphere with
+// Config.Greenhouse
+func surfaceTemperature(cfg Config, p models.Planet) int32 {
+	surface := p.EquilibriumTemp
+	if cfg.Greenhouse {
+		surface += greenhouseOffsets[p.Atmosphere]
+	}
+	return int32(math.Round(surface))
+}
+
+// setExoplanetClimate sets the insolation, temperatures and habitable-zone
+// flag of exoplanets from their final orbits around a host of the given
+// luminosity and effective temperature
+func setExoplanetClimate(luminosity float64, temperature int32, exoplanets []models.Exoplanet) {
+	for i := range exoplanets {
+		exo := &exoplanets[i]
+		exo.Insolation = insolation(luminosity, exo.SemiMajorAxis, exo.Eccentricity)
+		exo.EquilibriumTemp = equilibriumTemperature(luminosity, exo.SemiMajorAxis, exo.Eccentricity, exo.BondAlbedo)
+		exo.InHabitableZone = inHabitableZone(temperature, exo.Insolation)
+		exo.SurfaceTemp = int32(math.Round(exo.EquilibriumTemp))
+	}
+}
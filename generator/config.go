@@ -10,11 +10,124 @@ type Config struct {
 	PlanetsPerStar int   // Maximum number of planets per star
 	ExoPerStar     int   // Maximum number of exoplanets per star
 	Seed           int64 // Random seed for reproducibility
+	Workers        int   // Number of generator goroutines (0 or 1 generates sequentially)
+	ShardIndex     int   // 0-based shard of the star index space to generate
+	ShardCount     int   // Number of shards the dataset is split into (0 or 1 generates everything)
+
+	// LuminosityFractions is the relative population of each luminosity
+	// class, keyed by the Luminosity* constants. Nil uses
+	// DefaultLuminosityFractions.
+	LuminosityFractions map[string]float64
+
+	// StarModel selects how star parameters are drawn: StarModelUniform
+	// (the default when empty) or StarModelMass. StarScatter is the
+	// fractional log-normal scatter around the mass relations.
+	StarModel   string
+	StarScatter float64
+
+	// IMF, if set, samples main-sequence star masses from an initial mass
+	// function and derives the spectral class from mass instead of using
+	// class weights. Masses below 0.08 solar masses become brown dwarfs.
+	IMF *IMF
+
+	// SpatialModel selects the distribution of star positions and velocities:
+	// SpatialUniform (the default when empty), SpatialThinDisk or
+	// SpatialThickDisk. Stars lie within MaxDistance parsecs of the Sun
+	// (0 uses 3000 pc).
+	SpatialModel string
+	MaxDistance  float64
+
+	// OrbitSpacing, if set, builds each system's orbits from the inside out
+	// with OrbitSpacingHill or OrbitSpacingPeriodRatio spacing, so that
+	// every system passes IsStable. A host's planets and exoplanets are
+	// spaced together; those that cannot be placed stably are dropped and
+	// counted in System.Dropped. Empty draws orbits independently.
+	// Systems with an architecture template other than independent keep
+	// the template's orbits and drop the bodies that are not stable.
+	OrbitSpacing string
+
+	// Architectures, if set, are templates such as hot Jupiters or compact
+	// resonant chains, one of which is chosen by weight for each star and
+	// recorded in Star.Architecture. Nil draws every system independently.
+	Architectures []Architecture
+
+	// Occurrence, if set, draws the number and kind of planets and
+	// exoplanets of each star from occurrence rates that depend on its
+	// spectral class and metallicity. Otherwise counts are uniform up to
+	// PlanetsPerStar and ExoPerStar and kinds have fixed fractions.
+	Occurrence *OccurrenceConfig
+
+	// Multiplicity, if set, gives stars companions according to their
+	// spectral class. Planets of multiple systems orbit one component
+	// (S-type) or the inner binary (P-type) within the stable range.
+	Multiplicity *MultiplicityConfig
+
+	// LightCurves, if set, generates a light curve for every exoplanet
+	// detected by transit
+	LightCurves *LightCurveConfig
+
+	// RadialVelocities, if set, generates radial-velocity observations of
+	// every host star with exoplanets detected by radial velocity
+	RadialVelocities *RVConfig
+
+	// Surveys, if set, applies survey sensitivity models so that only some
+	// exoplanets are Detected. Otherwise every exoplanet is detected.
+	Surveys *SurveyConfig
+
+	// Greenhouse adds the warming of each planet's atmosphere to its
+	// equilibrium temperature to give SurfaceTemp
+	Greenhouse bool
+
+	// Compositions draws the molecular composition of each planet's
+	// atmosphere into Planet.Composition
+	Compositions bool
+
+	// Designations names stars after catalogs such as HD, HIP and 2MASS,
+	// letters planets b, c, d in discovery order and lists every alias of
+	// a star in StarIdentifiers. Otherwise stars are named "Star-<n>".
+	Designations bool
+}
+
+// shardRange returns the half-open range of star indices [start, end)
+// belonging to the configured shard. Shards are contiguous, so concatenating
+// shards 0..ShardCount-1 in order yields the complete dataset.
+func (cfg Config) shardRange() (start, end int) {
+	if cfg.ShardCount <= 1 {
+		return 0, cfg.NumStars
+	}
+
+	start = int(int64(cfg.NumStars) * int64(cfg.ShardIndex) / int64(cfg.ShardCount))
+	end = int(int64(cfg.NumStars) * int64(cfg.ShardIndex+1) / int64(cfg.ShardCount))
+	return start, end
 }
 
 // GeneratedData holds all generated entities
 type GeneratedData struct {
-	Stars      []models.Star
-	Planets    []models.Planet
-	Exoplanets []models.Exoplanet
+	Stars           []models.Star // Including companions
+	StarSystems     []models.StarSystem
+	StarIdentifiers []models.StarIdentifier
+	Planets         []models.Planet
+	Moons           []models.Moon
+	Exoplanets      []models.Exoplanet
+	LightCurves     []models.LightCurvePoint
+	RVObservations  []models.RVObservation
+}
+
+// System holds a single star together with the planets and exoplanets
+// generated for it. It is the unit produced by Stream.
+type System struct {
+	Star            models.Star
+	Companions      []models.Star           // Only with Config.Multiplicity
+	StarSystem      *models.StarSystem      // Links Star and Companions, nil for single stars
+	StarIdentifiers []models.StarIdentifier // Only with Config.Designations
+	Planets         []models.Planet
+	Moons           []models.Moon // Moons of Planets
+	Exoplanets      []models.Exoplanet
+	LightCurves     []models.LightCurvePoint // Only with Config.LightCurves
+	RVObservations  []models.RVObservation   // Only with Config.RadialVelocities
+
+	// Dropped counts the planets and exoplanets left out of the system
+	// because they could not be placed on a stable orbit: in a multiple
+	// system with no room around any host, or by Config.OrbitSpacing
+	Dropped int
 }
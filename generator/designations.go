@@ -0,0 +1,307 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// Star catalogs
+const (
+	CatalogKepler = "Kepler"
+	CatalogGliese = "GJ"
+	CatalogHD     = "HD"
+	CatalogHIP    = "HIP"
+	CatalogTOI    = "TOI"
+	Catalog2MASS  = "2MASS"
+	CatalogKOI    = "KOI"
+	CatalogKIC    = "KIC"
+	CatalogGaia   = "Gaia DR3"
+	CatalogTIC    = "TIC"
+)
+
+// catalog describes the numbering of a star catalog. Stars get numbers
+// from offset in a seeded order; size is a prime, and indices beyond it
+// continue in further blocks of size numbers.
+type catalog struct {
+	name   string
+	format string
+	offset uint64
+	size   uint64
+}
+
+// Catalogs numbered by star index, roughly the size of the real ones
+var (
+	keplerCatalog = catalog{CatalogKepler, "Kepler-%d", 1, 1999}
+	glieseCatalog = catalog{CatalogGliese, "GJ %d", 1, 4493}
+	hdCatalog     = catalog{CatalogHD, "HD %d", 1, 359069}
+	hipCatalog    = catalog{CatalogHIP, "HIP %d", 1, 118213}
+	toiCatalog    = catalog{CatalogTOI, "TOI-%d", 101, 6997}
+	koiCatalog    = catalog{CatalogKOI, "KOI-%d", 1, 7993}
+	kicCatalog    = catalog{CatalogKIC, "KIC %d", 757000, 11999989}
+	gaiaCatalog   = catalog{CatalogGaia, "Gaia DR3 %d", 1, 1<<61 - 1}
+	ticCatalog    = catalog{CatalogTIC, "TIC %d", 1, 999999937}
+)
+
+// Limits of the magnitude-limited catalogs in apparent bolometric
+// magnitude, and of the Gliese catalog of nearby stars in parsecs
+const (
+	hdMagnitudeLimit      = 9.5
+	hipMagnitudeLimit     = 12.4
+	twoMASSMagnitudeLimit = 16.0
+	kicMagnitudeLimit     = 17.0
+	gaiaMagnitudeLimit    = 21.0
+	glieseDistance        = 25.0
+)
+
+// The Kepler field of view, about 105 square degrees in Cygnus and Lyra
+const (
+	keplerFieldRA     = 290.67
+	keplerFieldDec    = 44.5
+	keplerFieldRadius = 5.8 // degrees
+)
+
+// solarBolometricMagnitude is the absolute bolometric magnitude of the Sun
+const solarBolometricMagnitude = 4.74
+
+// number returns the catalog number of the star at index. Numbers are a
+// permutation of the index keyed by the seed, so every star of a dataset
+// gets a different number without knowing about the others.
+func (c catalog) number(seed int64, index int) uint64 {
+	key := splitmix64(uint64(seed) ^ splitmix64(c.size))
+	i := uint64(index)
+
+	// Affine maps modulo a prime and the modular inverse are bijections;
+	// the inverse scatters neighbouring indices
+	n := c.affine(i%c.size, key)
+	n = c.power(n, c.size-2)
+	n = c.affine(n, splitmix64(key))
+	return c.offset + n + c.size*(i/c.size)
+}
+
+// affine returns a*x + b modulo size, with a and b drawn from key
+func (c catalog) affine(x, key uint64) uint64 {
+	a := 1 + key%(c.size-1)
+	b := splitmix64(key) % c.size
+	hi, lo := bits.Mul64(a, x)
+	return (bits.Rem64(hi, lo, c.size) + b) % c.size
+}
+
+// power returns x^e modulo size
+func (c catalog) power(x, e uint64) uint64 {
+	result := uint64(1)
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			hi, lo := bits.Mul64(result, x)
+			result = bits.Rem64(hi, lo, c.size)
+		}
+		hi, lo := bits.Mul64(x, x)
+		x = bits.Rem64(hi, lo, c.size)
+	}
+	return result
+}
+
+// identifier returns the designation of the star at index in the catalog
+func (c catalog) identifier(seed int64, index int) models.StarIdentifier {
+	return models.StarIdentifier{
+		Catalog:    c.name,
+		Identifier: fmt.Sprintf(c.format, c.number(seed, index)),
+	}
+}
+
+// apparentMagnitude returns the apparent bolometric magnitude of star
+func apparentMagnitude(star models.Star) float64 {
+	return solarBolometricMagnitude - 2.5*math.Log10(star.Luminosity) + 5*math.Log10(star.Distance/10)
+}
+
+// inKeplerField reports whether star lies within the Kepler field of view
+func inKeplerField(star models.Star) bool {
+	ra1, dec1 := star.RA*math.Pi/180, star.Dec*math.Pi/180
+	ra2, dec2 := keplerFieldRA*math.Pi/180, keplerFieldDec*math.Pi/180
+	cos := math.Sin(dec1)*math.Sin(dec2) + math.Cos(dec1)*math.Cos(dec2)*math.Cos(ra1-ra2)
+	return math.Acos(math.Min(1, cos))*180/math.Pi < keplerFieldRadius
+}
+
+// twoMASSIdentifier returns the 2MASS designation of star, which encodes
+// its position as Jhhmmssss+ddmmsss with truncated digits
+func twoMASSIdentifier(star models.Star) models.StarIdentifier {
+	ra := int64(star.RA / 15 * 3600 * 100) // centiseconds of time
+	sign := '+'
+	if star.Dec < 0 {
+		sign = '-'
+	}
+	dec := int64(math.Abs(star.Dec) * 3600 * 10) // deciarcseconds
+	return models.StarIdentifier{
+		Catalog: Catalog2MASS,
+		Identifier: fmt.Sprintf("2MASS J%02d%02d%04d%c%02d%02d%03d",
+			ra/360000, ra/6000%60, ra%6000, sign, dec/36000, dec/600%60, dec%600),
+	}
+}
+
+// starIdentifiers returns the catalog designations of the primary star at
+// index, preferred designation first. Transit hosts in the Kepler field
+// are Kepler planets and elsewhere TESS Objects of Interest. 2MASS
+// designations come from the position and can be shared by close stars, so
+// they come last, after the TIC number every star has.
+func starIdentifiers(seed int64, index int, star models.Star, exoplanets []models.Exoplanet) []models.StarIdentifier {
+	transitHost := false
+	for _, exo := range exoplanets {
+		if exo.Detected && exo.DetectionMethod == DetectionTransit {
+			transitHost = true
+		}
+	}
+	magnitude := apparentMagnitude(star)
+	keplerField := inKeplerField(star)
+
+	var ids []models.StarIdentifier
+	if transitHost && keplerField {
+		ids = append(ids, keplerCatalog.identifier(seed, index))
+	}
+	if star.Distance < glieseDistance {
+		ids = append(ids, glieseCatalog.identifier(seed, index))
+	}
+	if magnitude < hdMagnitudeLimit {
+		ids = append(ids, hdCatalog.identifier(seed, index))
+	}
+	if magnitude < hipMagnitudeLimit {
+		ids = append(ids, hipCatalog.identifier(seed, index))
+	}
+	if transitHost && !keplerField {
+		ids = append(ids, toiCatalog.identifier(seed, index))
+	}
+	if transitHost && keplerField {
+		ids = append(ids, koiCatalog.identifier(seed, index))
+	}
+	if keplerField && (magnitude < kicMagnitudeLimit || transitHost) {
+		ids = append(ids, kicCatalog.identifier(seed, index))
+	}
+	if magnitude < gaiaMagnitudeLimit {
+		ids = append(ids, gaiaCatalog.identifier(seed, index))
+	}
+	ids = append(ids, ticCatalog.identifier(seed, index))
+	if magnitude < twoMASSMagnitudeLimit {
+		ids = append(ids, twoMASSIdentifier(star))
+	}
+	return ids
+}
+
+// planetLetter returns the letter of the planet discovered i-th (0-based)
+// around its host: b, c, ..., z, then ba, bb, ...
+func planetLetter(i int) string {
+	const letters = "bcdefghijklmnopqrstuvwxyz"
+	if i < len(letters) {
+		return letters[i : i+1]
+	}
+	return planetLetter(i/len(letters)-1) + letters[i%len(letters):i%len(letters)+1]
+}
+
+// romanNumeral returns n (at least 1) in Roman numerals
+func romanNumeral(n int) string {
+	numerals := []struct {
+		value  int
+		symbol string
+	}{
+		{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+		{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+	}
+
+	var b strings.Builder
+	for _, numeral := range numerals {
+		for n >= numeral.value {
+			b.WriteString(numeral.symbol)
+			n -= numeral.value
+		}
+	}
+	return b.String()
+}
+
+// designate renames the stars of the system at index after their catalog
+// designations and lists them in StarIdentifiers. Components add their
+// letter, planets and exoplanets are lettered together in discovery order
+// around their host (circumbinary planets around "AB"), and moons are
+// numbered with Roman numerals.
+func designate(seed int64, index int, system *System) {
+	// The preferred designation is always from a numbered catalog, so that
+	// names are unique
+	ids := starIdentifiers(seed, index, system.Star, system.Exoplanets)
+	base := ids[0].Identifier
+	if system.StarSystem != nil {
+		system.StarSystem.Name = base
+	}
+
+	stars := []*models.Star{&system.Star}
+	for i := range system.Companions {
+		stars = append(stars, &system.Companions[i])
+	}
+	names := make(map[string]string, len(stars))
+	for _, star := range stars {
+		suffix := ""
+		if star.Component != "" {
+			suffix = " " + star.Component
+		}
+		star.Name = base + suffix
+		names[star.ID] = star.Name
+		for _, id := range ids {
+			system.StarIdentifiers = append(system.StarIdentifiers, models.StarIdentifier{
+				StarID:     star.ID,
+				Catalog:    id.Catalog,
+				Identifier: id.Identifier + suffix,
+			})
+		}
+	}
+
+	// Undiscovered exoplanets are lettered after the discovered ones, and
+	// planets discovered together by increasing period
+	type body struct {
+		host   string
+		year   int32
+		period float64
+		name   *string
+	}
+	var bodies []body
+	host := func(starID, orbitType string) string {
+		if orbitType == OrbitTypeP {
+			return base + " AB"
+		}
+		return names[starID]
+	}
+	for i := range system.Planets {
+		p := &system.Planets[i]
+		bodies = append(bodies, body{host(p.StarID, p.OrbitType), p.DiscoveryYear, p.OrbitalPeriod, &p.Name})
+	}
+	for i := range system.Exoplanets {
+		exo := &system.Exoplanets[i]
+		year := exo.DiscoveryYear
+		if !exo.Detected {
+			year = math.MaxInt32
+		}
+		bodies = append(bodies, body{host(exo.StarID, exo.OrbitType), year, exo.OrbitalPeriod, &exo.Name})
+	}
+	sort.SliceStable(bodies, func(i, j int) bool {
+		if bodies[i].year != bodies[j].year {
+			return bodies[i].year < bodies[j].year
+		}
+		return bodies[i].period < bodies[j].period
+	})
+
+	discovered := make(map[string]int)
+	for _, b := range bodies {
+		*b.name = b.host + " " + planetLetter(discovered[b.host])
+		discovered[b.host]++
+	}
+
+	planetNames := make(map[string]string, len(system.Planets))
+	for _, p := range system.Planets {
+		planetNames[p.ID] = p.Name
+	}
+	moons := make(map[string]int)
+	for i := range system.Moons {
+		moon := &system.Moons[i]
+		moons[moon.PlanetID]++
+		moon.Name = planetNames[moon.PlanetID] + " " + romanNumeral(moons[moon.PlanetID])
+	}
+}
@@ -0,0 +1,152 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// Exoplanet detection methods
+const (
+	DetectionTransit      = "Transit"
+	DetectionRV           = "Radial Velocity"
+	DetectionImaging      = "Direct Imaging"
+	DetectionMicrolensing = "Gravitational Microlensing"
+	DetectionAstrometry   = "Astrometry"
+	DetectionTTV          = "Transit Timing Variation"
+)
+
+// lastDiscoveryYear is the latest discovery year generated
+const lastDiscoveryYear = 2024
+
+// detectionMethod describes how readily a survey technique finds a planet
+type detectionMethod struct {
+	name      string
+	firstYear int32   // First discovery made with the method
+	yield     float64 // Relative number of planets the method's surveys find
+	// detectability returns the chance (0-1) that the method detects exo
+	detectability func(star models.Star, exo models.Exoplanet, siblings int) float64
+}
+
+// detectionMethods is sampled in this order, weighted by yield times
+// detectability. Yields are relative to radial-velocity surveys; transit
+// surveys monitor thousands of times more stars, so they carry the largest.
+var detectionMethods = []detectionMethod{
+	{DetectionTransit, 2002, 1000.0, transitDetectability},
+	{DetectionRV, 1995, 1.0, rvDetectability},
+	{DetectionImaging, 2004, 20.0, imagingDetectability},
+	{DetectionMicrolensing, 2003, 0.25, microlensingDetectability},
+	{DetectionAstrometry, 2013, 0.1, astrometryDetectability},
+	{DetectionTTV, 2011, 20.0, ttvDetectability},
+}
+
+// minDetectability keeps every method possible, however unlikely
+const minDetectability = 1e-4
+
+// Survey thresholds
+const (
+	minTransitDepth   = 100e-6 // Fractional flux drop
+	maxTransitPeriod  = 487.0  // days, three transits in a four-year survey
+	minRVAmplitude    = 3.0    // m/s
+	maxRVBaseline     = 7300.0 // days, two decades of monitoring
+	minImagingSep     = 0.2    // arcsec, inner working angle
+	imagingMass       = 1000.0 // Earth masses of a readily imaged young giant
+	imagingAge        = 0.5    // Gyr below which giants are still bright
+	einsteinRadius    = 3.5    // AU, for a solar-mass lens in the bulge
+	microlensingWidth = 0.7    // Natural-log width of the lensing zone
+	microlensingRange = 4000.0 // pc, distance of a typical bulge lens
+	minAstrometric    = 50.0   // micro-arcsec
+	astrometryPeriod  = 3650.0 // days, mission baseline
+)
+
+// assignDetectionMethods picks the detection method and discovery year of
+// every exoplanet of a system from how detectable it is by each technique
+func assignDetectionMethods(r *rand.Rand, star models.Star, exoplanets []models.Exoplanet) {
+	weights := make([]float64, len(detectionMethods))
+	for i := range exoplanets {
+		exo := &exoplanets[i]
+
+		total := 0.0
+		for j, method := range detectionMethods {
+			d := math.Max(method.detectability(star, *exo, len(exoplanets)-1), minDetectability)
+			weights[j] = method.yield * d
+			total += weights[j]
+		}
+
+		pick := r.Float64() * total
+		method := detectionMethods[len(detectionMethods)-1]
+		for j, weight := range weights {
+			if pick < weight {
+				method = detectionMethods[j]
+				break
+			}
+			pick -= weight
+		}
+
+		exo.DetectionMethod = method.name
+		exo.DiscoveryYear = discoveryYear(r, method.firstYear)
+	}
+}
+
+// discoveryYear draws a year between the method's first discovery and
+// lastDiscoveryYear, with the discovery rate growing linearly since then
+func discoveryYear(r *rand.Rand, firstYear int32) int32 {
+	span := float64(lastDiscoveryYear - firstYear + 1)
+	year := firstYear + int32(span*math.Sqrt(r.Float64()))
+	if year > lastDiscoveryYear {
+		year = lastDiscoveryYear
+	}
+	return year
+}
+
+// transitDetectability is the geometric transit probability R*/a times the
+// chance that the transit is deep enough and repeats often enough to detect
+func transitDetectability(star models.Star, exo models.Exoplanet, _ int) float64 {
+	starRadius := star.Radius * solarRadiusAU
+	probability := math.Min(1, starRadius/exo.SemiMajorAxis)
+	ratio := exo.Radius * earthRadiusAU / starRadius
+	return probability * math.Min(1, ratio*ratio/minTransitDepth) * math.Min(1, maxTransitPeriod/exo.OrbitalPeriod)
+}
+
+// rvDetectability scales with the semi-amplitude (for an edge-on orbit) and
+// falls off for periods longer than the monitoring baseline
+func rvDetectability(star models.Star, exo models.Exoplanet, _ int) float64 {
+	edgeOn := exo
+	edgeOn.Inclination = 90
+	K := rvSemiAmplitude(star, edgeOn)
+	return math.Min(1, K/minRVAmplitude) * math.Min(1, maxRVBaseline/exo.OrbitalPeriod)
+}
+
+// imagingDetectability requires the planet to be resolved from its star and
+// favours massive planets around young stars, which are still self-luminous
+func imagingDetectability(star models.Star, exo models.Exoplanet, _ int) float64 {
+	separation := exo.SemiMajorAxis / star.Distance // arcsec
+	if separation < minImagingSep {
+		return 0
+	}
+	return math.Min(1, exo.Mass/imagingMass) * math.Min(1, imagingAge/star.Age)
+}
+
+// microlensingDetectability peaks for planets near the Einstein radius of
+// distant stars
+func microlensingDetectability(star models.Star, exo models.Exoplanet, _ int) float64 {
+	x := math.Log(exo.SemiMajorAxis / (einsteinRadius * math.Sqrt(star.Mass)))
+	zone := math.Exp(-x * x / (2 * microlensingWidth * microlensingWidth))
+	return zone * math.Min(1, star.Distance/microlensingRange)
+}
+
+// astrometryDetectability scales with the astrometric wobble of the star
+// and requires the orbit to fit in the mission baseline
+func astrometryDetectability(star models.Star, exo models.Exoplanet, _ int) float64 {
+	wobble := exo.Mass * earthMassSolar / star.Mass * exo.SemiMajorAxis / star.Distance * 1e6 // micro-arcsec
+	return math.Min(1, wobble/minAstrometric) * math.Min(1, astrometryPeriod/exo.OrbitalPeriod)
+}
+
+// ttvDetectability needs a transiting planet with a sibling perturbing it
+func ttvDetectability(star models.Star, exo models.Exoplanet, siblings int) float64 {
+	if siblings == 0 {
+		return 0
+	}
+	return transitDetectability(star, exo, siblings) * math.Min(1, exo.Mass/10)
+}
@@ -0,0 +1,187 @@
+package generator
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// ephemerisBuffer is the number of ephemeris points buffered ahead of the
+// consumer
+const ephemerisBuffer = 1024
+
+// j2000 is the reference epoch J2000.0 (JD 2451545.0) as a time. The
+// difference between TT and UTC is ignored.
+var j2000 = time.Date(2000, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// EphemerisRange is the time range and cadence of an ephemeris. Points are
+// produced at Start, Start+Cadence, ... up to and including End. Cadence
+// must be positive.
+type EphemerisRange struct {
+	Start   time.Time
+	End     time.Time
+	Cadence time.Duration
+}
+
+// keplerElements are the elements that place a body on its orbit, with
+// angles in degrees and times in days
+type keplerElements struct {
+	period, semiMajorAxis, eccentricity       float64
+	inclination, node, periapsis, meanAnomaly float64
+	epoch                                     float64 // Julian Date
+}
+
+// PlanetPosition returns the position and velocity of a planet relative to
+// its star at time t, solving Kepler's equation from the planet's orbital
+// elements
+func PlanetPosition(planet models.Planet, t time.Time) models.EphemerisPoint {
+	point := keplerElements{
+		period:        planet.OrbitalPeriod,
+		semiMajorAxis: planet.SemiMajorAxis,
+		eccentricity:  planet.Eccentricity,
+		inclination:   planet.Inclination,
+		node:          planet.LongitudeOfAscendingNode,
+		periapsis:     planet.ArgumentOfPeriapsis,
+		meanAnomaly:   planet.MeanAnomaly,
+		epoch:         planet.Epoch,
+	}.at(t)
+	point.PlanetID = planet.ID
+	return point
+}
+
+// ExoplanetPosition returns the position and velocity of an exoplanet
+// relative to its star at time t
+func ExoplanetPosition(exo models.Exoplanet, t time.Time) models.EphemerisPoint {
+	point := exoplanetElements(exo).at(t)
+	point.PlanetID = exo.ID
+	return point
+}
+
+// exoplanetElements returns the orbital elements of an exoplanet
+func exoplanetElements(exo models.Exoplanet) keplerElements {
+	return keplerElements{
+		period:        exo.OrbitalPeriod,
+		semiMajorAxis: exo.SemiMajorAxis,
+		eccentricity:  exo.Eccentricity,
+		inclination:   exo.Inclination,
+		node:          exo.LongitudeOfAscendingNode,
+		periapsis:     exo.ArgumentOfPeriapsis,
+		meanAnomaly:   exo.MeanAnomaly,
+		epoch:         exo.Epoch,
+	}
+}
+
+// Ephemeris generates the configured dataset and streams the positions of
+// every planet and exoplanet over the given range. Points are ordered by
+// star, then body, then time, so each body's points are contiguous. The
+// channel is closed when all points have been sent or ctx is cancelled.
+func Ephemeris(ctx context.Context, cfg Config, rng EphemerisRange) <-chan models.EphemerisPoint {
+	out := make(chan models.EphemerisPoint, ephemerisBuffer)
+
+	go func() {
+		defer close(out)
+
+		// send emits the points of one body, reporting false once ctx is done
+		send := func(position func(time.Time) models.EphemerisPoint) bool {
+			if rng.Cadence <= 0 {
+				return false
+			}
+			for t := rng.Start; !t.After(rng.End); t = t.Add(rng.Cadence) {
+				select {
+				case out <- position(t):
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+
+		for system := range Stream(ctx, cfg) {
+			for _, planet := range system.Planets {
+				if !send(func(t time.Time) models.EphemerisPoint { return PlanetPosition(planet, t) }) {
+					return
+				}
+			}
+			for _, exo := range system.Exoplanets {
+				if !send(func(t time.Time) models.EphemerisPoint { return ExoplanetPosition(exo, t) }) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// at returns the position in AU and velocity in AU/day at time t. The
+// reference plane is the sky plane, with Z along the line of sight towards
+// the observer.
+func (k keplerElements) at(t time.Time) models.EphemerisPoint {
+	days := julianDate(t) - k.epoch
+	n := 2 * math.Pi / k.period // Mean motion in radians per day
+
+	meanAnomaly := math.Mod(k.meanAnomaly*math.Pi/180+n*days, 2*math.Pi)
+	e := k.eccentricity
+	E := solveKepler(meanAnomaly, e)
+	sinE, cosE := math.Sincos(E)
+
+	// Position and velocity in the orbital plane, periapsis along x
+	b := k.semiMajorAxis * math.Sqrt(1-e*e)
+	x := k.semiMajorAxis * (cosE - e)
+	y := b * sinE
+	rate := n / (1 - e*cosE) // dE/dt
+	vx := -k.semiMajorAxis * sinE * rate
+	vy := b * cosE * rate
+
+	// Rotate by argument of periapsis, inclination and ascending node
+	sinW, cosW := math.Sincos(k.periapsis * math.Pi / 180)
+	sinI, cosI := math.Sincos(k.inclination * math.Pi / 180)
+	sinO, cosO := math.Sincos(k.node * math.Pi / 180)
+	m := [3][3]float64{
+		{cosO*cosW - sinO*sinW*cosI, -cosO*sinW - sinO*cosW*cosI, sinO * sinI},
+		{sinO*cosW + cosO*sinW*cosI, -sinO*sinW + cosO*cosW*cosI, -cosO * sinI},
+		{sinW * sinI, cosW * sinI, cosI},
+	}
+	pos := rotate(m, [3]float64{x, y, 0})
+	vel := rotate(m, [3]float64{vx, vy, 0})
+
+	return models.EphemerisPoint{
+		Timestamp: t,
+		X:         pos[0],
+		Y:         pos[1],
+		Z:         pos[2],
+		VX:        vel[0],
+		VY:        vel[1],
+		VZ:        vel[2],
+	}
+}
+
+// julianDate converts a time to a Julian Date
+func julianDate(t time.Time) float64 {
+	return referenceEpoch + t.Sub(j2000).Hours()/24
+}
+
+// julianTime converts a Julian Date to a time
+func julianTime(jd float64) time.Time {
+	return j2000.Add(time.Duration((jd - referenceEpoch) * 24 * float64(time.Hour)))
+}
+
+// solveKepler solves Kepler's equation M = E - e sin E for the eccentric
+// anomaly E by Newton's method
+func solveKepler(meanAnomaly, e float64) float64 {
+	E := meanAnomaly
+	if e > 0.8 {
+		E = math.Pi
+	}
+
+	for i := 0; i < 50; i++ {
+		delta := (E - e*math.Sin(E) - meanAnomaly) / (1 - e*math.Cos(E))
+		E -= delta
+		if math.Abs(delta) < 1e-12 {
+			break
+		}
+	}
+	return E
+}
@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/rand"
@@ -9,40 +10,25 @@ import (
 	"github.com/google/uuid"
 )
 
-// Spectral types with their characteristics
-var spectralTypes = []struct {
+// spectralClass describes the physical ranges of one spectral class
+type spectralClass struct {
 	class       string
+	weight      float64    // Relative frequency within its luminosity class
 	massRange   [2]float64 // Min, Max in solar masses
 	radiusRange [2]float64 // Min, Max in solar radii
 	tempRange   [2]int32   // Min, Max in Kelvin
-}{
-	{"O", [2]float64{16.0, 90.0}, [2]float64{6.6, 15.0}, [2]int32{30000, 50000}},
-	{"B", [2]float64{2.1, 16.0}, [2]float64{1.8, 6.6}, [2]int32{10000, 30000}},
-	{"A", [2]float64{1.4, 2.1}, [2]float64{1.4, 1.8}, [2]int32{7500, 10000}},
-	{"F", [2]float64{1.04, 1.4}, [2]float64{1.15, 1.4}, [2]int32{6000, 7500}},
-	{"G", [2]float64{0.8, 1.04}, [2]float64{0.96, 1.15}, [2]int32{5200, 6000}},
-	{"K", [2]float64{0.45, 0.8}, [2]float64{0.7, 0.96}, [2]int32{3700, 5200}},
-	{"M", [2]float64{0.08, 0.45}, [2]float64{0.1, 0.7}, [2]int32{2400, 3700}},
-}
-
-var atmosphereTypes = []string{
-	"H2/He dominant",
-	"N2/O2 dominant",
-	"CO2 dominant",
-	"Thin atmosphere",
-	"No atmosphere",
-	"H2/He with methane",
-	"Sulfuric compounds",
-	"Water vapor rich",
-}
-
-var detectionMethods = []string{
-	"Transit",
-	"Radial Velocity",
-	"Direct Imaging",
-	"Gravitational Microlensing",
-	"Astrometry",
-	"Transit Timing Variation",
+}
+
+// Spectral types with their characteristics (main sequence).
+// Weighted by frequency; M stars are most common.
+var spectralTypes = []spectralClass{
+	{"O", 0.00003, [2]float64{16.0, 90.0}, [2]float64{6.6, 15.0}, [2]int32{30000, 50000}},
+	{"B", 0.13, [2]float64{2.1, 16.0}, [2]float64{1.8, 6.6}, [2]int32{10000, 30000}},
+	{"A", 2.0, [2]float64{1.4, 2.1}, [2]float64{1.4, 1.8}, [2]int32{7500, 10000}},
+	{"F", 3.0, [2]float64{1.04, 1.4}, [2]float64{1.15, 1.4}, [2]int32{6000, 7500}},
+	{"G", 7.6, [2]float64{0.8, 1.04}, [2]float64{0.96, 1.15}, [2]int32{5200, 6000}},
+	{"K", 12.1, [2]float64{0.45, 0.8}, [2]float64{0.7, 0.96}, [2]int32{3700, 5200}},
+	{"M", 76.45, [2]float64{0.08, 0.45}, [2]float64{0.1, 0.7}, [2]int32{2400, 3700}},
 }
 
 // randFloat generates a random float64 between min and max
@@ -55,10 +41,14 @@ func randInt(r *rand.Rand, min, max int32) int32 {
 	return min + r.Int31n(max-min+1)
 }
 
-// generateSpectralType generates a realistic spectral type
-func generateSpectralType(r *rand.Rand) string {
-	// Weight spectral types by frequency (M stars are most common)
-	weights := []float64{0.00003, 0.13, 2.0, 3.0, 7.6, 12.1, 76.45}
+// newID derives a UUID (version 4 layout) from the seeded random stream so
+// that a given seed reproduces entity IDs and foreign keys exactly
+func newID(r *rand.Rand) string {
+	return uuid.Must(uuid.NewRandomFromReader(r)).String()
+}
+
+// weightedChoice picks an index with probability proportional to its weight
+func weightedChoice(r *rand.Rand, weights []float64) int {
 	total := 0.0
 	for _, w := range weights {
 		total += w
@@ -66,87 +56,181 @@ func generateSpectralType(r *rand.Rand) string {
 
 	val := r.Float64() * total
 	cumulative := 0.0
-	classIdx := 0
 
 	for i, w := range weights {
 		cumulative += w
 		if val <= cumulative {
-			classIdx = i
-			break
+			return i
 		}
 	}
 
-	class := spectralTypes[classIdx].class
-	subclass := r.Intn(10)
-	luminosity := "V" // Main sequence
+	return len(weights) - 1
+}
+
+// generateLuminosityClass picks a luminosity class according to the
+// configured population fractions
+func generateLuminosityClass(r *rand.Rand, cfg Config) string {
+	fractions := cfg.LuminosityFractions
+	if fractions == nil {
+		fractions = DefaultLuminosityFractions
+	}
 
-	return fmt.Sprintf("%s%d%s", class, subclass, luminosity)
+	weights := make([]float64, len(luminosityOrder))
+	for i, luminosity := range luminosityOrder {
+		weights[i] = fractions[luminosity]
+	}
+	return luminosityOrder[weightedChoice(r, weights)]
 }
 
-// generateStar creates a realistic star
-func generateStar(r *rand.Rand, index int) models.Star {
-	spectralType := generateSpectralType(r)
-	classChar := spectralType[0]
-
-	// Find spectral class characteristics
-	var classIdx int
-	for i, st := range spectralTypes {
-		if st.class[0] == classChar {
-			classIdx = i
-			break
-		}
+// generateSpectralClass picks a spectral class weighted by frequency within
+// a luminosity class
+func generateSpectralClass(r *rand.Rand, luminosity string) spectralClass {
+	classes := luminosityClasses[luminosity]
+	weights := make([]float64, len(classes))
+	for i, sc := range classes {
+		weights[i] = sc.weight
 	}
 
-	st := spectralTypes[classIdx]
+	return classes[weightedChoice(r, weights)]
+}
+
+// generateStar creates a realistic star
+func generateStar(r *rand.Rand, cfg Config, index int) models.Star {
+	luminosity := generateLuminosityClass(r, cfg)
+
+	// With an IMF, main-sequence masses are sampled first and the class
+	// follows from mass; otherwise mass is uniform within a weighted class
+	var mass float64
+	var st spectralClass
+	if cfg.IMF != nil && luminosity == LuminosityMainSequence {
+		mass = cfg.IMF.sample(r)
+		luminosity, st = classForMass(mass)
+	} else {
+		st = generateSpectralClass(r, luminosity)
+	}
 
 	star := models.Star{
-		ID:           uuid.New().String(),
-		Name:         fmt.Sprintf("Star-%d", index),
-		SpectralType: spectralType,
-		Mass:         randFloat(r, st.massRange[0], st.massRange[1]),
-		Radius:       randFloat(r, st.radiusRange[0], st.radiusRange[1]),
-		Temperature:  randInt(r, st.tempRange[0], st.tempRange[1]),
+		ID:   newID(r),
+		Name: fmt.Sprintf("Star-%d", index),
+	}
+	if mass > 0 {
+		star.Mass = mass
+	} else {
+		star.Mass = randFloat(r, st.massRange[0], st.massRange[1])
 	}
 
+	// In the mass model, main-sequence radius and temperature follow from
+	// mass, and the spectral class follows from temperature
+	if cfg.StarModel == StarModelMass && luminosity == LuminosityMainSequence {
+		star.Radius, star.Temperature = mainSequenceParameters(r, star.Mass, cfg.StarScatter)
+		st = mainSequenceClassForTemperature(star.Temperature)
+	} else {
+		star.Radius = randFloat(r, st.radiusRange[0], st.radiusRange[1])
+		star.Temperature = randInt(r, st.tempRange[0], st.tempRange[1])
+	}
+
+	// White dwarf subclasses encode temperature; in the mass model all
+	// subclasses agree with temperature, otherwise they are drawn
+	var subclass int
+	switch {
+	case luminosity == LuminosityWhiteDwarf:
+		subclass = whiteDwarfSubclass(star.Temperature)
+	case cfg.StarModel == StarModelMass:
+		subclass = subclassForTemperature(st, star.Temperature)
+	default:
+		subclass = r.Intn(10)
+	}
+	star.SpectralType = formatSpectralType(luminosity, st.class, subclass)
+
+	star.Luminosity = stellarLuminosity(star.Radius, star.Temperature)
+	star.SurfaceGravity = surfaceGravity(star.Mass, star.Radius)
+	setHabitableZone(&star)
+	star.Age = stellarAge(r, luminosity, star.Mass)
+	star.Metallicity = metallicity(r, star.Age)
+
+	placeStar(r, cfg, &star)
+
 	return star
 }
 
-// generatePlanet creates a realistic planet orbiting a star
-func generatePlanet(r *rand.Rand, star models.Star, index int) models.Planet {
-	// Orbital parameters
-	semiMajorAxis := randFloat(r, 0.05, 50.0) // AU
-	orbitalPeriod := 365.25 * math.Sqrt(semiMajorAxis*semiMajorAxis*semiMajorAxis/star.Mass)
+// maxStellarAge is the age in Gyr of the oldest stars in the Galactic disk
+const maxStellarAge = 13.0
+
+// stellarAge draws an age in Gyr consistent with the evolutionary state of
+// a star: main-sequence stars are younger than their main-sequence lifetime,
+// evolved stars have just exceeded it, and remnants and brown dwarfs can
+// have any age up to the age of the disk
+func stellarAge(r *rand.Rand, luminosity string, mass float64) float64 {
+	switch luminosity {
+	case LuminosityMainSequence:
+		// The most massive stars live for less than 10 Myr
+		lifetime := math.Min(maxStellarAge, mainSequenceLifetime(mass))
+		return randFloat(r, math.Min(0.01, 0.1*lifetime), lifetime)
+	case LuminositySubgiant, LuminosityGiant, LuminosityBrightGiant, LuminositySupergiant:
+		return math.Min(maxStellarAge, mainSequenceLifetime(mass)*randFloat(r, 1.0, 1.2))
+	case LuminosityWhiteDwarf:
+		return randFloat(r, 0.5, maxStellarAge)
+	default:
+		return randFloat(r, 0.1, maxStellarAge)
+	}
+}
 
-	// Planet type determines mass and radius
-	planetType := r.Float64()
-	var mass, radius float64
+// metallicity draws [Fe/H] from an age-metallicity relation in which older
+// stars are more metal-poor, with a scatter of 0.15 dex
+func metallicity(r *rand.Rand, age float64) float64 {
+	feH := 0.2 - 0.05*age + 0.15*r.NormFloat64()
+	return math.Max(-2.5, math.Min(0.5, feH))
+}
 
-	if planetType < 0.3 { // Rocky planet
-		mass = randFloat(r, 0.1, 5.0)
-		radius = randFloat(r, 0.3, 2.0)
-	} else if planetType < 0.6 { // Ice giant
-		mass = randFloat(r, 5.0, 20.0)
-		radius = randFloat(r, 2.0, 4.0)
-	} else { // Gas giant
-		mass = randFloat(r, 20.0, 1000.0)
-		radius = randFloat(r, 4.0, 15.0)
+// orbitRange returns the semi-major axis range [min, max] in AU for a planet
+// drawn from the nominal range, moved outward so that orbits around giant
+// stars stay well clear of the stellar surface
+func orbitRange(star models.Star, min, max float64) (float64, float64) {
+	surface := 2.0 * star.Radius * solarRadiusAU
+	if min < surface {
+		min = surface
 	}
+	if max < 2.0*min {
+		max = 2.0 * min
+	}
+	return min, max
+}
 
-	// Temperature decreases with distance
-	surfaceTemp := int32(float64(star.Temperature) * math.Sqrt(star.Radius/(2.0*semiMajorAxis)))
+// orbitalPeriod returns the period in days of an orbit around star with the
+// given semi-major axis in AU (Kepler's third law)
+func orbitalPeriod(star models.Star, semiMajorAxis float64) float64 {
+	return 365.25 * math.Sqrt(semiMajorAxis*semiMajorAxis*semiMajorAxis/star.Mass)
+}
+
+// generatePlanet creates a realistic planet orbiting a star in the given
+// slot. A slot without a kind draws it from its occurrence rates, if any.
+func generatePlanet(r *rand.Rand, star models.Star, index int, slot planetSlot) models.Planet {
+	// Orbital parameters
+	minAxis, maxAxis := orbitRange(star, slot.axis[0], slot.axis[1])
+	semiMajorAxis := randFloat(r, minAxis, maxAxis) // AU
+	orbitalPeriod := orbitalPeriod(star, semiMajorAxis)
+
+	// Planet kind determines mass, mass determines radius
+	kind := slot.kind
+	if kind == kindAny {
+		kind = planetKind(r, slot.rates)
+	}
+	masses := planetMassRanges[kind]
+	mass := randFloat(r, masses[0], masses[1])
+	radius := planetRadius(r, mass)
+	class := planetClass(radius)
 
 	planet := models.Planet{
-		ID:            uuid.New().String(),
+		ID:            newID(r),
 		Name:          fmt.Sprintf("%s-Planet-%d", star.Name, index),
 		OrbitalPeriod: orbitalPeriod,
 		SemiMajorAxis: semiMajorAxis,
-		Eccentricity:  randFloat(r, 0.0, 0.3),
+		Eccentricity:  randFloat(r, slot.eccentricity[0], slot.eccentricity[1]),
 		Mass:          mass,
 		Radius:        radius,
-		Atmosphere:    atmosphereTypes[r.Intn(len(atmosphereTypes))],
-		SurfaceTemp:   surfaceTemp,
+		BondAlbedo:    bondAlbedo(r, class),
+		PlanetClass:   class,
 		HasRings:      r.Float64() < 0.2,
-		HasMoons:      r.Float64() < 0.6,
 		DiscoveryYear: randInt(r, 1990, 2024),
 		StarID:        star.ID,
 	}
@@ -154,68 +238,191 @@ func generatePlanet(r *rand.Rand, star models.Star, index int) models.Planet {
 	return planet
 }
 
-// generateExoplanet creates a realistic exoplanet
-func generateExoplanet(r *rand.Rand, star models.Star, index int) models.Exoplanet {
+// generateExoplanet creates a realistic exoplanet in the given slot. A slot
+// without a kind or occurrence rates has a mass uniform over all kinds.
+func generateExoplanet(r *rand.Rand, star models.Star, index int, slot planetSlot) models.Exoplanet {
 	// Orbital parameters
-	semiMajorAxis := randFloat(r, 0.01, 5.0) // AU (closer range for detectability)
-	orbitalPeriod := 365.25 * math.Sqrt(semiMajorAxis*semiMajorAxis*semiMajorAxis/star.Mass)
+	minAxis, maxAxis := orbitRange(star, slot.axis[0], slot.axis[1])
+	semiMajorAxis := randFloat(r, minAxis, maxAxis) // AU (closer range for detectability)
+	orbitalPeriod := orbitalPeriod(star, semiMajorAxis)
 
 	// Mass and radius
-	mass := randFloat(r, 0.5, 500.0)
-	radius := randFloat(r, 0.5, 12.0)
-
-	// Temperature
-	surfaceTemp := int32(float64(star.Temperature) * math.Sqrt(star.Radius/(2.0*semiMajorAxis)))
-
-	// Host distance
-	hostDistance := randFloat(r, 10.0, 10000.0)
+	masses := [2]float64{exoplanetMassRanges[kindRocky][0], exoplanetMassRanges[kindGiant][1]}
+	switch {
+	case slot.kind != kindAny:
+		masses = exoplanetMassRanges[slot.kind]
+	case slot.rates != nil:
+		masses = exoplanetMassRanges[weightedChoice(r, slot.rates)]
+	}
+	mass := randFloat(r, masses[0], masses[1])
+	radius := planetRadius(r, mass)
+	class := planetClass(radius)
 
 	exoplanet := models.Exoplanet{
-		ID:              uuid.New().String(),
-		Name:            fmt.Sprintf("%s-Exo-%d", star.Name, index),
-		OrbitalPeriod:   orbitalPeriod,
-		SemiMajorAxis:   semiMajorAxis,
-		Eccentricity:    randFloat(r, 0.0, 0.5),
-		Mass:            mass,
-		Radius:          radius,
-		DetectionMethod: detectionMethods[r.Intn(len(detectionMethods))],
-		HostDistance:    hostDistance,
-		SurfaceTemp:     surfaceTemp,
-		DiscoveryYear:   randInt(r, 1990, 2024),
-		StarID:          star.ID,
+		ID:            newID(r),
+		Name:          fmt.Sprintf("%s-Exo-%d", star.Name, index),
+		OrbitalPeriod: orbitalPeriod,
+		SemiMajorAxis: semiMajorAxis,
+		Eccentricity:  randFloat(r, slot.eccentricity[0], slot.eccentricity[1]),
+		Mass:          mass,
+		Radius:        radius,
+		BondAlbedo:    bondAlbedo(r, class),
+		PlanetClass:   class,
+		HostDistance:  star.Distance * lightYearsPerParsec,
+		Detected:      true,
+		StarID:        star.ID,
 	}
 
 	return exoplanet
 }
 
-// GenerateAll generates all stellar data based on configuration
-func GenerateAll(cfg Config) *GeneratedData {
-	r := rand.New(rand.NewSource(cfg.Seed))
+// GenerateSystem regenerates the star at index (0-based, named
+// "Star-<index+1>") together with its planets and exoplanets. Every star
+// draws from its own random stream derived from cfg.Seed and the index, so
+// the result is identical to the corresponding system in GenerateAll or
+// Stream output without generating any other star. cfg.NumStars and
+// cfg.Workers are not used.
+func GenerateSystem(cfg Config, index int) System {
+	r := systemRand(cfg.Seed, index)
+	star := generateStar(r, cfg, index+1)
+
+	// Companions make the star the primary of a multiple system, whose
+	// planets orbit one component or the inner binary
+	var system System
+	hosts := []planetHost{singleHost(star)}
+	if cfg.Multiplicity != nil {
+		system.Companions, system.StarSystem = generateCompanions(r, cfg, &star)
+		if system.StarSystem != nil {
+			hosts = multipleHosts(*system.StarSystem, star, system.Companions)
+		}
+	}
+
+	// An architecture template places the planets itself; independent
+	// systems draw every orbit and kind on their own
+	arch := chooseArchitecture(r, cfg.Architectures)
+	independent := arch == nil || arch.Independent
+	if arch != nil {
+		star.Architecture = arch.Name
+	}
+	system.Star = star
+
+	var planets []models.Planet
+	var exoplanets []models.Exoplanet
+	if independent {
+		// Generate planets for this star, 0 to PlanetsPerStar
+		rates := occurrenceRates(cfg, star)
+		slot := independentPlanet
+		slot.rates = rates
+		numPlanets := planetCount(r, rates, cfg.PlanetsPerStar)
+		for j := 0; j < numPlanets; j++ {
+			planets = append(planets, generatePlanet(r, star, j+1, slot))
+		}
+
+		// Generate exoplanets for this star, 0 to ExoPerStar
+		slot = independentExoplanet
+		slot.rates = rates
+		numExoplanets := planetCount(r, rates, cfg.ExoPerStar)
+		for j := 0; j < numExoplanets; j++ {
+			exoplanets = append(exoplanets, generateExoplanet(r, star, j+1, slot))
+		}
+	} else {
+		planets, exoplanets = architectureBodies(r, star, *arch, cfg.PlanetsPerStar, cfg.ExoPerStar)
+	}
 
+	groups := []hostGroup{{host: hosts[0], planets: planets, exoplanets: exoplanets}}
+	if len(hosts) > 1 {
+		groups, system.Dropped = groupByHost(r, hosts, planets, exoplanets)
+	}
+
+	// Rebuild orbits in order so that neighbouring orbits are well
+	// separated; templates keep their orbits and lose the bodies that are
+	// too close
+	if cfg.OrbitSpacing != "" {
+		for i := range groups {
+			g := &groups[i]
+			var dropped int
+			if independent {
+				g.planets, g.exoplanets, dropped = spaceBodies(r, cfg, g.host, g.planets, g.exoplanets)
+			} else {
+				g.planets, g.exoplanets, dropped = pruneBodies(g.host, g.planets, g.exoplanets)
+			}
+			system.Dropped += dropped
+		}
+	}
+
+	// Climate, atmospheres and detection depend on the final orbits
+	for _, g := range groups {
+		setPlanetClimate(g.host.luminosity, g.host.temperature, g.planets)
+		setExoplanetClimate(g.host.luminosity, g.host.temperature, g.exoplanets)
+		setAtmospheres(r, cfg, g.planets)
+		assignDetectionMethods(r, g.host.star, g.exoplanets)
+	}
+
+	system.Planets, system.Exoplanets = gatherGroups(groups)
+	orientOrbits(r, system.Planets, system.Exoplanets)
+	for _, g := range groups {
+		alignTransits(r, g.host.star, g.exoplanets)
+	}
+
+	mr := auxRand(cfg.Seed, index, streamMoons)
+	for _, g := range groups {
+		system.Moons = append(system.Moons, generateMoons(mr, g.host.star, g.planets)...)
+	}
+
+	// Surveys, light curves and radial velocities draw from separate streams
+	if cfg.Surveys != nil {
+		sr := auxRand(cfg.Seed, index, streamSurveys)
+		for _, g := range groups {
+			applySurveys(sr, *cfg.Surveys, g.host.star, g.exoplanets)
+		}
+	}
+	if cfg.LightCurves != nil {
+		lr := auxRand(cfg.Seed, index, streamLightCurves)
+		for _, g := range groups {
+			system.LightCurves = append(system.LightCurves, generateLightCurves(lr, *cfg.LightCurves, g.host.star, g.exoplanets)...)
+		}
+	}
+	if cfg.Compositions {
+		cr := auxRand(cfg.Seed, index, streamCompositions)
+		setCompositions(cr, system.Planets)
+	}
+	if cfg.RadialVelocities != nil {
+		vr := auxRand(cfg.Seed, index, streamRadialVelocities)
+		for _, g := range groups {
+			system.RVObservations = append(system.RVObservations, generateRVObservations(vr, *cfg.RadialVelocities, g.host.star, g.exoplanets)...)
+		}
+	}
+
+	// Designations depend on detections and are not drawn
+	if cfg.Designations {
+		designate(cfg.Seed, index, &system)
+	}
+
+	return system
+}
+
+// GenerateAll generates all stellar data of the configured shard.
+// The whole dataset is held in memory; use Stream for large datasets.
+func GenerateAll(cfg Config) *GeneratedData {
 	data := &GeneratedData{
 		Stars:      make([]models.Star, 0, cfg.NumStars),
 		Planets:    make([]models.Planet, 0),
+		Moons:      make([]models.Moon, 0),
 		Exoplanets: make([]models.Exoplanet, 0),
 	}
 
-	// Generate stars
-	for i := 0; i < cfg.NumStars; i++ {
-		star := generateStar(r, i+1)
-		data.Stars = append(data.Stars, star)
-
-		// Generate planets for this star
-		numPlanets := r.Intn(cfg.PlanetsPerStar + 1) // 0 to PlanetsPerStar
-		for j := 0; j < numPlanets; j++ {
-			planet := generatePlanet(r, star, j+1)
-			data.Planets = append(data.Planets, planet)
-		}
-
-		// Generate exoplanets for this star
-		numExoplanets := r.Intn(cfg.ExoPerStar + 1) // 0 to ExoPerStar
-		for j := 0; j < numExoplanets; j++ {
-			exoplanet := generateExoplanet(r, star, j+1)
-			data.Exoplanets = append(data.Exoplanets, exoplanet)
+	for system := range Stream(context.Background(), cfg) {
+		data.Stars = append(data.Stars, system.Star)
+		data.Stars = append(data.Stars, system.Companions...)
+		if system.StarSystem != nil {
+			data.StarSystems = append(data.StarSystems, *system.StarSystem)
 		}
+		data.StarIdentifiers = append(data.StarIdentifiers, system.StarIdentifiers...)
+		data.Planets = append(data.Planets, system.Planets...)
+		data.Moons = append(data.Moons, system.Moons...)
+		data.Exoplanets = append(data.Exoplanets, system.Exoplanets...)
+		data.LightCurves = append(data.LightCurves, system.LightCurves...)
+		data.RVObservations = append(data.RVObservations, system.RVObservations...)
 	}
 
 	return data
@@ -0,0 +1,193 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Initial mass function models
+const (
+	IMFSalpeter = "salpeter" // Single power law (Salpeter 1955)
+	IMFKroupa   = "kroupa"   // Broken power law (Kroupa 2001)
+	IMFChabrier = "chabrier" // Log-normal below 1 solar mass, power law above (Chabrier 2003)
+)
+
+// Default IMF parameters from the published fits
+var (
+	defaultSalpeterSlopes = []float64{2.35}
+	defaultKroupaSlopes   = []float64{0.3, 1.3, 2.3}
+	defaultChabrierSlopes = []float64{2.3}
+	kroupaBreaks          = []float64{0.08, 0.5}
+)
+
+const (
+	defaultIMFMinMass         = 0.08
+	defaultIMFMaxMass         = 100.0
+	defaultChabrierMass       = 0.079
+	defaultChabrierSigma      = 0.69
+	chabrierPowerLawThreshold = 1.0
+)
+
+// IMF describes an initial mass function that star masses are sampled from.
+// Zero-valued fields select the published parameters of the model.
+type IMF struct {
+	Model   string  // IMFSalpeter, IMFKroupa or IMFChabrier
+	MinMass float64 // Lower mass bound in solar masses (default 0.08)
+	MaxMass float64 // Upper mass bound in solar masses (default 100)
+
+	// Slopes are power-law exponents alpha of dN/dM ∝ M^-alpha. Salpeter
+	// uses one slope, Kroupa three (below 0.08, 0.08-0.5 and above 0.5
+	// solar masses) and Chabrier one (above 1 solar mass).
+	Slopes []float64
+
+	// CharacteristicMass and Sigma (in dex) shape the Chabrier log-normal
+	CharacteristicMass float64
+	Sigma              float64
+}
+
+// imfSegment is one piece of an IMF over the mass range [lo, hi], either a
+// power law k*M^-alpha or a log-normal in log10 M with mean mu and width sigma
+type imfSegment struct {
+	lo, hi    float64
+	k, alpha  float64
+	lognormal bool
+	mu, sigma float64
+	weight    float64 // Relative number of stars in the segment
+}
+
+// segments splits the IMF into pieces clipped to its mass bounds
+func (imf *IMF) segments() []imfSegment {
+	minMass, maxMass := imf.MinMass, imf.MaxMass
+	if minMass <= 0 {
+		minMass = defaultIMFMinMass
+	}
+	if maxMass <= 0 {
+		maxMass = defaultIMFMaxMass
+	}
+
+	var segments []imfSegment
+	switch imf.Model {
+	case IMFKroupa:
+		slopes := imf.slopes(defaultKroupaSlopes)
+		edges := append([]float64{0}, kroupaBreaks...)
+		edges = append(edges, math.Inf(1))
+
+		// Keep the broken power law continuous at each break
+		k := 1.0
+		for i := range slopes {
+			if i > 0 {
+				k *= math.Pow(edges[i], slopes[i]-slopes[i-1])
+			}
+			segments = append(segments, imfSegment{lo: edges[i], hi: edges[i+1], k: k, alpha: slopes[i]})
+		}
+	case IMFChabrier:
+		mc, sigma := imf.CharacteristicMass, imf.Sigma
+		if mc <= 0 {
+			mc = defaultChabrierMass
+		}
+		if sigma <= 0 {
+			sigma = defaultChabrierSigma
+		}
+		alpha := imf.slopes(defaultChabrierSlopes)[0]
+
+		// The power law joins the log-normal (per unit log10 M) at 1 solar mass
+		logMc := math.Log10(mc)
+		k := math.Exp(-logMc*logMc/(2*sigma*sigma)) / math.Ln10
+		segments = append(segments,
+			imfSegment{lo: 0, hi: chabrierPowerLawThreshold, lognormal: true, mu: logMc, sigma: sigma},
+			imfSegment{lo: chabrierPowerLawThreshold, hi: math.Inf(1), k: k, alpha: alpha},
+		)
+	default:
+		slopes := imf.slopes(defaultSalpeterSlopes)
+		segments = append(segments, imfSegment{lo: 0, hi: math.Inf(1), k: 1, alpha: slopes[0]})
+	}
+
+	for i := range segments {
+		s := &segments[i]
+		s.lo = math.Max(s.lo, minMass)
+		s.hi = math.Min(s.hi, maxMass)
+		if s.hi > s.lo {
+			s.weight = s.integral()
+		}
+	}
+
+	return segments
+}
+
+// slopes returns the configured slopes, or defaults if too few are set
+func (imf *IMF) slopes(defaults []float64) []float64 {
+	if len(imf.Slopes) < len(defaults) {
+		return defaults
+	}
+	return imf.Slopes[:len(defaults)]
+}
+
+// integral returns the relative number of stars in the segment
+func (s imfSegment) integral() float64 {
+	if s.lognormal {
+		za := (math.Log10(s.lo) - s.mu) / s.sigma
+		zb := (math.Log10(s.hi) - s.mu) / s.sigma
+		return s.sigma * math.Sqrt(2*math.Pi) * (normalCDF(zb) - normalCDF(za))
+	}
+	if s.alpha == 1 {
+		return s.k * math.Log(s.hi/s.lo)
+	}
+	p := 1 - s.alpha
+	return s.k * (math.Pow(s.hi, p) - math.Pow(s.lo, p)) / p
+}
+
+// sample draws a mass from the segment by inverting its cumulative distribution
+func (s imfSegment) sample(r *rand.Rand) float64 {
+	u := r.Float64()
+	if s.lognormal {
+		ca := normalCDF((math.Log10(s.lo) - s.mu) / s.sigma)
+		cb := normalCDF((math.Log10(s.hi) - s.mu) / s.sigma)
+		z := math.Sqrt2 * math.Erfinv(2*(ca+u*(cb-ca))-1)
+		return math.Pow(10, s.mu+s.sigma*z)
+	}
+	if s.alpha == 1 {
+		return s.lo * math.Pow(s.hi/s.lo, u)
+	}
+	p := 1 - s.alpha
+	lo, hi := math.Pow(s.lo, p), math.Pow(s.hi, p)
+	return math.Pow(lo+u*(hi-lo), 1/p)
+}
+
+// sample draws a star mass in solar masses from the IMF
+func (imf *IMF) sample(r *rand.Rand) float64 {
+	segments := imf.segments()
+	weights := make([]float64, len(segments))
+	for i, s := range segments {
+		weights[i] = s.weight
+	}
+
+	mass := segments[weightedChoice(r, weights)].sample(r)
+	minMass, maxMass := segments[0].lo, segments[len(segments)-1].hi
+	return math.Max(minMass, math.Min(maxMass, mass))
+}
+
+// normalCDF is the standard normal cumulative distribution function
+func normalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+// classForMass returns the luminosity class and spectral class of a star of
+// the given mass: brown dwarfs below the hydrogen-burning limit, the
+// main-sequence class whose mass range contains it otherwise
+func classForMass(mass float64) (string, spectralClass) {
+	switch {
+	case mass < 0.03:
+		return LuminosityBrownDwarfY, brownDwarfYTypes[0]
+	case mass < 0.06:
+		return LuminosityBrownDwarfT, brownDwarfTTypes[0]
+	case mass < 0.08:
+		return LuminosityBrownDwarfL, brownDwarfLTypes[0]
+	}
+
+	for _, sc := range spectralTypes {
+		if mass >= sc.massRange[0] {
+			return LuminosityMainSequence, sc
+		}
+	}
+	return LuminosityMainSequence, spectralTypes[len(spectralTypes)-1]
+}
@@ -0,0 +1,156 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// LightCurveConfig sets up the photometric time series generated for
+// transiting exoplanets. A zero Cadence or Duration takes the default.
+type LightCurveConfig struct {
+	Cadence  time.Duration // Time between flux measurements (default 30 minutes)
+	Duration time.Duration // Length of each light curve (default 27 days)
+	Noise    float64       // Gaussian flux noise, 1 sigma in ppm (0 for none)
+}
+
+// Light curve defaults, roughly a TESS sector of full-frame images
+const (
+	defaultLightCurveCadence  = 30 * time.Minute
+	defaultLightCurveDuration = 27 * 24 * time.Hour
+)
+
+// Quadratic limb-darkening coefficients of a Sun-like star
+const (
+	limbDarkeningU1 = 0.40
+	limbDarkeningU2 = 0.26
+)
+
+// earthRadiusAU is one Earth radius in AU
+const earthRadiusAU = 4.2635e-5
+
+// maxTransitImpact is the largest impact parameter, in stellar radii, given
+// to transit-detected exoplanets
+const maxTransitImpact = 0.9
+
+// alignTransits tilts the orbits of transit-detected exoplanets so that they
+// cross the stellar disk, drawing the impact parameter uniformly. The
+// orbit's sense (prograde or retrograde on the sky) is kept.
+func alignTransits(r *rand.Rand, star models.Star, exoplanets []models.Exoplanet) {
+	starRadius := star.Radius * solarRadiusAU
+	for i := range exoplanets {
+		exo := &exoplanets[i]
+		if exo.DetectionMethod != DetectionTransit {
+			continue
+		}
+
+		// Projected star-planet distance at conjunction, in AU
+		e := exo.Eccentricity
+		sinW := math.Sin(exo.ArgumentOfPeriapsis * math.Pi / 180)
+		distance := exo.SemiMajorAxis * (1 - e*e) / (1 + e*sinW)
+
+		impact := randFloat(r, 0, maxTransitImpact)
+		inclination := degrees(math.Acos(math.Min(1, impact*starRadius/distance)))
+		if exo.Inclination > 90 {
+			inclination = 180 - inclination
+		}
+		exo.Inclination = inclination
+	}
+}
+
+// generateLightCurves returns the light curves of the transit-detected
+// exoplanets of a system
+func generateLightCurves(r *rand.Rand, cfg LightCurveConfig, star models.Star, exoplanets []models.Exoplanet) []models.LightCurvePoint {
+	if cfg.Cadence <= 0 {
+		cfg.Cadence = defaultLightCurveCadence
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = defaultLightCurveDuration
+	}
+
+	var points []models.LightCurvePoint
+	for _, exo := range exoplanets {
+		if exo.DetectionMethod == DetectionTransit {
+			points = append(points, lightCurve(r, cfg, star, exo)...)
+		}
+	}
+	return points
+}
+
+// lightCurve returns the normalised flux of star over cfg.Duration centred on
+// the first mid-transit of exo after its epoch
+func lightCurve(r *rand.Rand, cfg LightCurveConfig, star models.Star, exo models.Exoplanet) []models.LightCurvePoint {
+	elements := exoplanetElements(exo)
+	starRadius := star.Radius * solarRadiusAU
+	ratio := exo.Radius * earthRadiusAU / starRadius
+	sigma := cfg.Noise * 1e-6
+
+	start := julianTime(elements.conjunction()).Add(-cfg.Duration / 2)
+	end := start.Add(cfg.Duration)
+
+	var points []models.LightCurvePoint
+	for t := start; !t.After(end); t = t.Add(cfg.Cadence) {
+		pos := elements.at(t)
+
+		// Only a planet in front of the star blocks light
+		deficit := 0.0
+		if pos.Z > 0 {
+			separation := math.Hypot(pos.X, pos.Y) / starRadius
+			deficit = transitDeficit(separation, ratio)
+		}
+
+		points = append(points, models.LightCurvePoint{
+			ExoplanetID: exo.ID,
+			Time:        julianDate(t),
+			Flux:        1 - deficit + sigma*r.NormFloat64(),
+			FluxError:   sigma,
+			InTransit:   deficit > 0,
+		})
+	}
+	return points
+}
+
+// conjunction returns the Julian Date of the first inferior conjunction
+// (mid-transit for a transiting orbit) after the epoch
+func (k keplerElements) conjunction() float64 {
+	e := k.eccentricity
+	trueAnomaly := math.Pi/2 - k.periapsis*math.Pi/180
+	E := 2 * math.Atan(math.Sqrt((1-e)/(1+e))*math.Tan(trueAnomaly/2))
+	meanAnomaly := E - e*math.Sin(E)
+
+	delta := math.Mod(meanAnomaly-k.meanAnomaly*math.Pi/180, 2*math.Pi)
+	if delta < 0 {
+		delta += 2 * math.Pi
+	}
+	return k.epoch + delta/(2*math.Pi)*k.period
+}
+
+// transitDeficit returns the fraction of stellar flux blocked by a planet of
+// radius ratio k (planet/star) at projected separation z in stellar radii.
+// The occulted area is exact for uniform disks; limb darkening is applied
+// with the intensity at the planet's position (small-planet approximation).
+func transitDeficit(z, k float64) float64 {
+	if z >= 1+k {
+		return 0
+	}
+
+	var area float64
+	switch {
+	case z <= k-1:
+		area = math.Pi
+	case z <= 1-k:
+		area = math.Pi * k * k
+	default:
+		k0 := math.Acos(math.Max(-1, math.Min(1, (z*z+k*k-1)/(2*z*k))))
+		k1 := math.Acos(math.Max(-1, math.Min(1, (z*z+1-k*k)/(2*z))))
+		area = k*k*k0 + k1 - 0.5*math.Sqrt(math.Max(0, (1+k-z)*(z+k-1)*(z-k+1)*(z+k+1)))
+	}
+
+	mu := math.Sqrt(1 - math.Min(z, 1)*math.Min(z, 1))
+	intensity := 1 - limbDarkeningU1*(1-mu) - limbDarkeningU2*(1-mu)*(1-mu)
+	mean := 1 - limbDarkeningU1/3 - limbDarkeningU2/6
+
+	return area / math.Pi * intensity / mean
+}
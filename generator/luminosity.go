@@ -0,0 +1,149 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+)
+
+// Luminosity classes and stellar remnant/substellar families.
+// Giants and supergiants use the Morgan-Keenan suffix; white dwarfs
+// (D) and brown dwarfs (L, T, Y) carry their own spectral prefixes.
+const (
+	LuminosityMainSequence = "V"
+	LuminositySubgiant     = "IV"
+	LuminosityGiant        = "III"
+	LuminosityBrightGiant  = "II"
+	LuminositySupergiant   = "I"
+	LuminosityWhiteDwarf   = "D"
+	LuminosityBrownDwarfL  = "L"
+	LuminosityBrownDwarfT  = "T"
+	LuminosityBrownDwarfY  = "Y"
+)
+
+// luminosityOrder fixes the order in which luminosity classes are sampled,
+// since map iteration order is not deterministic
+var luminosityOrder = []string{
+	LuminosityMainSequence,
+	LuminositySubgiant,
+	LuminosityGiant,
+	LuminosityBrightGiant,
+	LuminositySupergiant,
+	LuminosityWhiteDwarf,
+	LuminosityBrownDwarfL,
+	LuminosityBrownDwarfT,
+	LuminosityBrownDwarfY,
+}
+
+// DefaultLuminosityFractions is the population mix used when
+// Config.LuminosityFractions is nil. It roughly follows a volume-limited
+// sample of the solar neighbourhood.
+var DefaultLuminosityFractions = map[string]float64{
+	LuminosityMainSequence: 0.80,
+	LuminositySubgiant:     0.03,
+	LuminosityGiant:        0.01,
+	LuminosityBrightGiant:  0.0005,
+	LuminositySupergiant:   0.0002,
+	LuminosityWhiteDwarf:   0.06,
+	LuminosityBrownDwarfL:  0.04,
+	LuminosityBrownDwarfT:  0.04,
+	LuminosityBrownDwarfY:  0.02,
+}
+
+// Subgiants (luminosity class IV)
+var subgiantTypes = []spectralClass{
+	{"B", 0.5, [2]float64{3.0, 15.0}, [2]float64{3.0, 8.0}, [2]int32{10000, 30000}},
+	{"A", 2.0, [2]float64{1.5, 3.0}, [2]float64{2.0, 4.0}, [2]int32{7500, 10000}},
+	{"F", 5.0, [2]float64{1.1, 2.0}, [2]float64{1.7, 3.0}, [2]int32{6000, 7500}},
+	{"G", 8.0, [2]float64{0.9, 1.6}, [2]float64{1.8, 3.5}, [2]int32{5000, 6000}},
+	{"K", 3.0, [2]float64{0.8, 1.4}, [2]float64{2.0, 5.0}, [2]int32{4500, 5200}},
+}
+
+// Giants (luminosity class III), dominated by red clump K giants
+var giantTypes = []spectralClass{
+	{"B", 0.5, [2]float64{4.0, 15.0}, [2]float64{5.0, 10.0}, [2]int32{10000, 30000}},
+	{"A", 1.0, [2]float64{2.0, 4.0}, [2]float64{3.0, 6.0}, [2]int32{7500, 10000}},
+	{"F", 1.5, [2]float64{1.5, 3.0}, [2]float64{3.0, 8.0}, [2]int32{6000, 7500}},
+	{"G", 20.0, [2]float64{1.0, 3.0}, [2]float64{5.0, 15.0}, [2]int32{4900, 5600}},
+	{"K", 60.0, [2]float64{0.8, 3.0}, [2]float64{10.0, 40.0}, [2]int32{3800, 4900}},
+	{"M", 17.0, [2]float64{0.8, 3.0}, [2]float64{40.0, 200.0}, [2]int32{3000, 3800}},
+}
+
+// Bright giants (luminosity class II)
+var brightGiantTypes = []spectralClass{
+	{"B", 2.0, [2]float64{8.0, 20.0}, [2]float64{10.0, 30.0}, [2]int32{10000, 28000}},
+	{"A", 2.0, [2]float64{5.0, 10.0}, [2]float64{15.0, 40.0}, [2]int32{7500, 10000}},
+	{"F", 2.0, [2]float64{4.0, 8.0}, [2]float64{20.0, 50.0}, [2]int32{6000, 7500}},
+	{"G", 3.0, [2]float64{3.0, 8.0}, [2]float64{25.0, 70.0}, [2]int32{4800, 6000}},
+	{"K", 5.0, [2]float64{3.0, 8.0}, [2]float64{40.0, 150.0}, [2]int32{3800, 4800}},
+	{"M", 3.0, [2]float64{3.0, 8.0}, [2]float64{100.0, 400.0}, [2]int32{3000, 3800}},
+}
+
+// Supergiants (luminosity class I)
+var supergiantTypes = []spectralClass{
+	{"O", 1.0, [2]float64{20.0, 100.0}, [2]float64{15.0, 30.0}, [2]int32{25000, 45000}},
+	{"B", 4.0, [2]float64{10.0, 40.0}, [2]float64{20.0, 80.0}, [2]int32{10000, 28000}},
+	{"A", 2.0, [2]float64{8.0, 20.0}, [2]float64{40.0, 100.0}, [2]int32{7500, 10000}},
+	{"F", 1.0, [2]float64{8.0, 20.0}, [2]float64{60.0, 150.0}, [2]int32{6000, 7500}},
+	{"G", 1.0, [2]float64{8.0, 20.0}, [2]float64{80.0, 250.0}, [2]int32{4500, 6000}},
+	{"K", 2.0, [2]float64{8.0, 25.0}, [2]float64{150.0, 600.0}, [2]int32{3600, 4500}},
+	{"M", 3.0, [2]float64{8.0, 30.0}, [2]float64{300.0, 1500.0}, [2]int32{3000, 3600}},
+}
+
+// White dwarfs by atmospheric subtype (DA hydrogen, DB helium, DC featureless,
+// DO ionised helium, DQ carbon, DZ metals)
+var whiteDwarfTypes = []spectralClass{
+	{"DA", 80.0, [2]float64{0.45, 1.2}, [2]float64{0.008, 0.02}, [2]int32{5000, 80000}},
+	{"DB", 8.0, [2]float64{0.5, 1.0}, [2]float64{0.008, 0.015}, [2]int32{11000, 30000}},
+	{"DC", 7.0, [2]float64{0.5, 1.0}, [2]float64{0.008, 0.015}, [2]int32{4000, 11000}},
+	{"DO", 1.0, [2]float64{0.5, 0.8}, [2]float64{0.01, 0.02}, [2]int32{45000, 120000}},
+	{"DQ", 2.0, [2]float64{0.5, 1.0}, [2]float64{0.008, 0.015}, [2]int32{5000, 12000}},
+	{"DZ", 2.0, [2]float64{0.5, 1.0}, [2]float64{0.008, 0.015}, [2]int32{5000, 12000}},
+}
+
+// Brown dwarfs, one spectral family each
+var (
+	brownDwarfLTypes = []spectralClass{
+		{"L", 1.0, [2]float64{0.06, 0.08}, [2]float64{0.08, 0.12}, [2]int32{1300, 2400}},
+	}
+	brownDwarfTTypes = []spectralClass{
+		{"T", 1.0, [2]float64{0.03, 0.07}, [2]float64{0.08, 0.12}, [2]int32{550, 1300}},
+	}
+	brownDwarfYTypes = []spectralClass{
+		{"Y", 1.0, [2]float64{0.01, 0.03}, [2]float64{0.08, 0.12}, [2]int32{250, 550}},
+	}
+)
+
+// luminosityClasses maps each luminosity class to its spectral classes
+var luminosityClasses = map[string][]spectralClass{
+	LuminosityMainSequence: spectralTypes,
+	LuminositySubgiant:     subgiantTypes,
+	LuminosityGiant:        giantTypes,
+	LuminosityBrightGiant:  brightGiantTypes,
+	LuminositySupergiant:   supergiantTypes,
+	LuminosityWhiteDwarf:   whiteDwarfTypes,
+	LuminosityBrownDwarfL:  brownDwarfLTypes,
+	LuminosityBrownDwarfT:  brownDwarfTTypes,
+	LuminosityBrownDwarfY:  brownDwarfYTypes,
+}
+
+// formatSpectralType builds the spectral type string for a star.
+// Normal stars carry the luminosity class suffix (e.g. "K2III"), while white
+// dwarfs and brown dwarfs use their own prefix only (e.g. "DA4", "T6").
+func formatSpectralType(luminosity, class string, subclass int) string {
+	switch luminosity {
+	case LuminosityWhiteDwarf, LuminosityBrownDwarfL, LuminosityBrownDwarfT, LuminosityBrownDwarfY:
+		return fmt.Sprintf("%s%d", class, subclass)
+	default:
+		return fmt.Sprintf("%s%d%s", class, subclass, luminosity)
+	}
+}
+
+// whiteDwarfSubclass returns the temperature index of a white dwarf,
+// 50400 K divided by the effective temperature (e.g. DA2 for 25000 K)
+func whiteDwarfSubclass(temperature int32) int {
+	index := int(math.Round(50400.0 / float64(temperature)))
+	if index < 1 {
+		index = 1
+	}
+	return index
+}
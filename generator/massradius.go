@@ -0,0 +1,78 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Planet classes by radius
+const (
+	PlanetClassTerrestrial = "terrestrial"
+	PlanetClassSuperEarth  = "super-Earth"
+	PlanetClassSubNeptune  = "sub-Neptune"
+	PlanetClassNeptune     = "Neptune"
+	PlanetClassJovian      = "Jovian"
+)
+
+// massRadiusSegment is one power law R = C M^S of the mass-radius relation,
+// in Earth units, valid up to maxMass with scatter sigma in log10 R
+type massRadiusSegment struct {
+	maxMass float64
+	scale   float64
+	slope   float64
+	sigma   float64
+}
+
+// massRadiusRelation is the probabilistic mass-radius relation of Chen &
+// Kipping (2017) for terran, Neptunian and Jovian worlds. Scales are chosen
+// so that the mean relation is continuous.
+var massRadiusRelation = []massRadiusSegment{
+	{2.04, 1.008, 0.279, 0.0403},
+	{131.6, 0.8083, 0.589, 0.146},
+	{math.Inf(1), 17.74, -0.044, 0.0737},
+}
+
+// Radius of a pure-iron planet, R = C M^S in Earth units, the densest a
+// planet can be (after Zeng et al. 2016)
+const (
+	ironRadiusScale = 0.77
+	ironRadiusSlope = 0.27
+)
+
+// planetClassRadii are the upper radius bounds (Earth radii) of each class
+var planetClassRadii = []struct {
+	class     string
+	maxRadius float64
+}{
+	{PlanetClassTerrestrial, 1.25},
+	{PlanetClassSuperEarth, 1.75},
+	{PlanetClassSubNeptune, 3.5},
+	{PlanetClassNeptune, 8.0},
+	{PlanetClassJovian, math.Inf(1)},
+}
+
+// planetRadius draws the radius in Earth radii of a planet of the given mass
+// in Earth masses from the mass-radius relation, no denser than pure iron
+func planetRadius(r *rand.Rand, mass float64) float64 {
+	segment := massRadiusRelation[len(massRadiusRelation)-1]
+	for _, s := range massRadiusRelation {
+		if mass < s.maxMass {
+			segment = s
+			break
+		}
+	}
+
+	logRadius := math.Log10(segment.scale) + segment.slope*math.Log10(mass) + segment.sigma*r.NormFloat64()
+	return math.Max(math.Pow(10, logRadius), ironRadiusScale*math.Pow(mass, ironRadiusSlope))
+}
+
+// planetClass returns the class of a planet with the given radius in Earth
+// radii
+func planetClass(radius float64) string {
+	for _, c := range planetClassRadii {
+		if radius < c.maxRadius {
+			return c.class
+		}
+	}
+	return PlanetClassJovian
+}
@@ -0,0 +1,156 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// Physical constants of planets and moons
+const (
+	kmPerAU       = 1.495978707e8
+	earthRadiusKm = 6371.0
+	earthMassKg   = 5.9722e24
+	earthDensity  = 5.514       // g/cm^3
+	earthGM       = 398600.4418 // km^3/s^2
+)
+
+// The mean number of moons grows with planet mass, from one for every two
+// Earth-mass planets to five for Jupiter-mass planets. Only regular moons
+// and large irregular ones are generated.
+const (
+	moonRate         = 0.5
+	moonMassExponent = 0.4
+	maxMoons         = 20
+)
+
+// moonMassRatioRanges are the ranges of log10 moon-to-planet mass ratio of
+// each planet class. Rocky planets can have moons up to twice the relative
+// mass of Earth's Moon; giant moons are at most about as massive as Triton
+// relative to Neptune.
+var moonMassRatioRanges = map[string][2]float64{
+	PlanetClassTerrestrial: {-8, -1.7},
+	PlanetClassSuperEarth:  {-8, -1.7},
+	PlanetClassSubNeptune:  {-10, -3.6},
+	PlanetClassNeptune:     {-10, -3.6},
+	PlanetClassJovian:      {-10, -3.6},
+}
+
+// moonDensityRange is the bulk density range of moons in g/cm^3, from icy
+// to rocky. Moons denser than rockyMoonDensity use the rigidity of rock.
+var moonDensityRange = [2]float64{1.0, 3.5}
+
+const rockyMoonDensity = 2.5
+
+// Rigidities in N/m^2 used for the tidal locking timescale
+const (
+	rockRigidity = 3e10
+	iceRigidity  = 4e9
+)
+
+// minMoonOrbit is the smallest moon orbit in planet radii. Low-density
+// giants have a Roche limit for rocky moons below their cloud tops.
+const minMoonOrbit = 1.5
+
+// Prograde moons on circular orbits are stable out to 0.4895 Hill radii,
+// less on eccentric planetary orbits (Domingos et al. 2006)
+const (
+	moonHillFraction     = 0.4895
+	moonHillEccentricity = 1.0305
+)
+
+// poisson draws from a Poisson distribution with the given mean
+func poisson(r *rand.Rand, mean float64) int {
+	limit := math.Exp(-mean)
+	n, p := 0, r.Float64()
+	for p > limit {
+		n++
+		p *= r.Float64()
+	}
+	return n
+}
+
+// moonStabilityLimit returns the widest stable moon orbit in km around a
+// planet orbiting star
+func moonStabilityLimit(star models.Star, p models.Planet) float64 {
+	hill := p.SemiMajorAxis * (1 - p.Eccentricity) * math.Cbrt(p.Mass*earthMassSolar/(3*star.Mass))
+	return moonHillFraction * (1 - moonHillEccentricity*p.Eccentricity) * hill * kmPerAU
+}
+
+// innerMoonLimit returns the smallest orbit in km of a moon of the given
+// density in g/cm^3: its rigid-body Roche limit, but at least minMoonOrbit
+// planet radii
+func innerMoonLimit(p models.Planet, density float64) float64 {
+	planetDensity := earthDensity * p.Mass / (p.Radius * p.Radius * p.Radius)
+	roche := math.Cbrt(2 * planetDensity / density)
+	return math.Max(roche, minMoonOrbit) * p.Radius * earthRadiusKm
+}
+
+// tidalLockTime returns the time in years for a moon to become tidally
+// locked to its planet (Gladman et al. 1996)
+func tidalLockTime(p models.Planet, moon models.Moon, rigidity float64) float64 {
+	a := moon.OrbitalRadius * 1e3
+	radius := moon.Radius * earthRadiusKm * 1e3
+	planetMass := p.Mass * earthMassKg
+	return 6 * math.Pow(a, 6) * radius * rigidity / (moon.Mass * earthMassKg * planetMass * planetMass) * 1e10
+}
+
+// generateMoons creates the moons of every planet orbiting star and sets
+// HasMoons to match
+func generateMoons(r *rand.Rand, star models.Star, planets []models.Planet) []models.Moon {
+	var moons []models.Moon
+	for i := range planets {
+		planetMoons := generatePlanetMoons(r, star, planets[i])
+		planets[i].HasMoons = len(planetMoons) > 0
+		moons = append(moons, planetMoons...)
+	}
+	return moons
+}
+
+// generatePlanetMoons creates the moons of one planet, numbered from the
+// inside out. Each moon orbits between its Roche limit and the stability
+// limit of the planet's Hill sphere; moons with no room are dropped.
+func generatePlanetMoons(r *rand.Rand, star models.Star, p models.Planet) []models.Moon {
+	n := min(poisson(r, moonRate*math.Pow(p.Mass, moonMassExponent)), maxMoons)
+	maxRadius := moonStabilityLimit(star, p)
+	ratios := moonMassRatioRanges[p.PlanetClass]
+
+	var moons []models.Moon
+	for j := 0; j < n; j++ {
+		density := randFloat(r, moonDensityRange[0], moonDensityRange[1])
+		mass := p.Mass * math.Pow(10, randFloat(r, ratios[0], ratios[1]))
+		minRadius := innerMoonLimit(p, density)
+		if minRadius >= maxRadius {
+			continue
+		}
+		orbitalRadius := minRadius * math.Exp(r.Float64()*math.Log(maxRadius/minRadius))
+
+		moon := models.Moon{
+			ID:            newID(r),
+			OrbitalRadius: orbitalRadius,
+			Mass:          mass,
+			Radius:        math.Cbrt(mass * earthDensity / density),
+			PlanetID:      p.ID,
+		}
+		moon.OrbitalPeriod = 2 * math.Pi * math.Sqrt(math.Pow(orbitalRadius, 3)/(earthGM*(p.Mass+mass))) / 86400
+
+		rigidity := iceRigidity
+		if density >= rockyMoonDensity {
+			rigidity = rockRigidity
+		}
+		moon.TidallyLocked = tidalLockTime(p, moon, rigidity) < star.Age*1e9
+
+		moons = append(moons, moon)
+	}
+
+	sort.Slice(moons, func(a, b int) bool {
+		return moons[a].OrbitalRadius < moons[b].OrbitalRadius
+	})
+	for j := range moons {
+		moons[j].Name = fmt.Sprintf("%s-Moon-%d", p.Name, j+1)
+	}
+	return moons
+}
@@ -0,0 +1,393 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// Planet orbit types in multiple star systems
+const (
+	OrbitTypeS = "S-type" // Orbits one component
+	OrbitTypeP = "P-type" // Orbits the inner binary (circumbinary)
+)
+
+// MultiplicityConfig turns generated stars into the primaries of binary and
+// triple systems
+type MultiplicityConfig struct {
+	// Fractions is the fraction of primaries with companions, keyed by the
+	// spectral class letter that starts SpectralType (O, B, A, F, G, K, M,
+	// D, L, T, Y). Nil uses DefaultMultiplicityFractions.
+	Fractions map[string]float64
+
+	// TripleFraction is the fraction of multiple systems with a third star
+	TripleFraction float64
+}
+
+// DefaultMultiplicityFractions is the fraction of primaries of each class
+// with companions, after Duchêne & Kraus (2013)
+var DefaultMultiplicityFractions = map[string]float64{
+	"O": 0.70,
+	"B": 0.60,
+	"A": 0.50,
+	"F": 0.46,
+	"G": 0.44,
+	"K": 0.41,
+	"M": 0.26,
+	"D": 0.25,
+	"L": 0.22,
+	"T": 0.22,
+	"Y": 0.22,
+}
+
+// Companion orbits follow the log-normal period distribution of solar-type
+// binaries (Raghavan et al. 2010), with masses from a flat mass-ratio
+// distribution
+const (
+	binaryLogPeriodMean   = 5.03 // log10 days
+	binaryLogPeriodSigma  = 2.28
+	maxBinaryLogPeriod    = 8.0  // log10 days, about 4000 AU for a solar pair
+	circularizationPeriod = 12.0 // days, below which orbits are circular
+	maxBinaryEccentricity = 0.8
+	minMassRatio          = 0.1
+	minCompanionMass      = 0.013 // Deuterium-burning limit in solar masses
+	minContactSeparation  = 3.0   // Periapsis in sums of stellar radii
+	maxOrbitAttempts      = 20
+)
+
+// fractions returns the configured multiplicity fractions or the defaults
+func (cfg MultiplicityConfig) fractions() map[string]float64 {
+	if cfg.Fractions == nil {
+		return DefaultMultiplicityFractions
+	}
+	return cfg.Fractions
+}
+
+// generateCompanions decides whether primary has companions and generates
+// them. Companions share the primary's position, motion, age and
+// metallicity. It returns nil for single stars.
+func generateCompanions(r *rand.Rand, cfg Config, primary *models.Star) ([]models.Star, *models.StarSystem) {
+	if r.Float64() >= cfg.Multiplicity.fractions()[primary.SpectralType[:1]] {
+		return nil, nil
+	}
+
+	secondary := generateCompanion(r, cfg, *primary, "B")
+	contact := minContactSeparation * (primary.Radius + secondary.Radius) * solarRadiusAU
+	period, separation, eccentricity, ok := binaryOrbit(r, primary.Mass+secondary.Mass, func(a, e float64) bool {
+		return a*(1-e) >= contact
+	})
+	if !ok {
+		return nil, nil
+	}
+
+	system := &models.StarSystem{
+		ID:           newID(r),
+		Name:         primary.Name,
+		Multiplicity: 2,
+		PrimaryID:    primary.ID,
+		SecondaryID:  secondary.ID,
+		Separation:   separation,
+		Period:       period,
+		Eccentricity: eccentricity,
+		MassRatio:    secondary.Mass / primary.Mass,
+	}
+	primary.SystemID, primary.Component = system.ID, "A"
+	secondary.SystemID = system.ID
+	companions := []models.Star{secondary}
+
+	// A tertiary needs an outer orbit wide enough for the hierarchy to be
+	// stable (Mardling & Aarseth 2001)
+	if r.Float64() < cfg.Multiplicity.TripleFraction {
+		tertiary := generateCompanion(r, cfg, *primary, "C")
+		inner := primary.Mass + secondary.Mass
+		q := tertiary.Mass / inner
+		period, separation, eccentricity, ok := binaryOrbit(r, inner+tertiary.Mass, func(a, e float64) bool {
+			limit := 2.8 * math.Pow(1+q, 0.4) * math.Pow(1+e, 0.4) * math.Pow(1-e, -1.2)
+			return a >= limit*system.Separation
+		})
+		if ok {
+			tertiary.SystemID = system.ID
+			companions = append(companions, tertiary)
+
+			system.Multiplicity = 3
+			system.TertiaryID = tertiary.ID
+			system.OuterSeparation = separation
+			system.OuterPeriod = period
+			system.OuterEccentricity = eccentricity
+			system.OuterMassRatio = q
+		}
+	}
+
+	return companions, system
+}
+
+// generateCompanion creates a companion of primary with a mass drawn from a
+// flat mass-ratio distribution. Main-sequence parameters follow from mass.
+func generateCompanion(r *rand.Rand, cfg Config, primary models.Star, component string) models.Star {
+	minRatio := math.Max(minMassRatio, minCompanionMass/primary.Mass)
+	mass := primary.Mass * randFloat(r, math.Min(minRatio, 1), 1)
+	luminosity, st := classForMass(mass)
+
+	star := models.Star{
+		ID:        newID(r),
+		Name:      primary.Name + " " + component,
+		Mass:      mass,
+		Component: component,
+	}
+
+	var subclass int
+	if luminosity == LuminosityMainSequence {
+		star.Radius, star.Temperature = mainSequenceParameters(r, mass, cfg.StarScatter)
+		st = mainSequenceClassForTemperature(star.Temperature)
+		subclass = subclassForTemperature(st, star.Temperature)
+	} else {
+		star.Radius = randFloat(r, st.radiusRange[0], st.radiusRange[1])
+		star.Temperature = randInt(r, st.tempRange[0], st.tempRange[1])
+		subclass = r.Intn(10)
+	}
+	star.SpectralType = formatSpectralType(luminosity, st.class, subclass)
+
+	star.Luminosity = stellarLuminosity(star.Radius, star.Temperature)
+	star.SurfaceGravity = surfaceGravity(star.Mass, star.Radius)
+	setHabitableZone(&star)
+	star.Age = primary.Age
+	star.Metallicity = primary.Metallicity
+
+	star.RA, star.Dec = primary.RA, primary.Dec
+	star.Distance, star.Parallax = primary.Distance, primary.Parallax
+	star.PMRA, star.PMDec = primary.PMRA, primary.PMDec
+	star.RadialVelocity = primary.RadialVelocity
+
+	return star
+}
+
+// binaryOrbit draws the period in days, semi-major axis in AU and
+// eccentricity of an orbit of the given total mass in solar masses until
+// one is accepted. It fails after maxOrbitAttempts rejections.
+func binaryOrbit(r *rand.Rand, mass float64, accept func(a, e float64) bool) (float64, float64, float64, bool) {
+	for attempt := 0; attempt < maxOrbitAttempts; attempt++ {
+		logP := binaryLogPeriodMean + binaryLogPeriodSigma*r.NormFloat64()
+		period := math.Pow(10, math.Max(0, math.Min(maxBinaryLogPeriod, logP)))
+
+		eccentricity := 0.0
+		if period >= circularizationPeriod {
+			eccentricity = randFloat(r, 0, maxBinaryEccentricity)
+		}
+
+		years := period / 365.25
+		a := math.Cbrt(mass * years * years)
+		if accept(a, eccentricity) {
+			return period, a, eccentricity, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// sTypeLimit returns the largest stable semi-major axis of a planet
+// orbiting one star of a binary with separation a and eccentricity e, where
+// mu is the companion's fraction of the total mass (Holman & Wiegert 1999)
+func sTypeLimit(a, e, mu float64) float64 {
+	return a * (0.464 - 0.380*mu - 0.631*e + 0.586*mu*e + 0.150*e*e - 0.198*mu*e*e)
+}
+
+// pTypeLimit returns the smallest stable semi-major axis of a planet
+// orbiting both stars of a binary with separation a and eccentricity e,
+// where mu is the secondary's fraction of the total mass (Holman & Wiegert 1999)
+func pTypeLimit(a, e, mu float64) float64 {
+	return a * (1.60 + 5.10*e - 2.22*e*e + 4.12*mu - 4.27*e*mu - 5.09*mu*mu + 4.61*e*e*mu*mu)
+}
+
+// planetHost is a star, or an inner binary, that planets can orbit,
+// together with the range of semi-major axes in AU that is stable around it
+type planetHost struct {
+	star      models.Star
+	orbitType string
+	minAxis   float64
+	maxAxis   float64
+
+	// Luminosity in solar units and effective temperature of the light the
+	// planets receive, from both stars of an inner binary
+	luminosity  float64
+	temperature int32
+}
+
+// singleHost returns the host of the planets of a single star
+func singleHost(star models.Star) planetHost {
+	return starHost(star, "", 0, math.Inf(1))
+}
+
+// starHost returns star as the host of orbits of orbitType between minAxis
+// and maxAxis
+func starHost(star models.Star, orbitType string, minAxis, maxAxis float64) planetHost {
+	return planetHost{
+		star:        star,
+		orbitType:   orbitType,
+		minAxis:     minAxis,
+		maxAxis:     maxAxis,
+		luminosity:  star.Luminosity,
+		temperature: star.Temperature,
+	}
+}
+
+// holds reports whether an orbit with the given semi-major axis is stable
+// around the host
+func (h planetHost) holds(semiMajorAxis float64) bool {
+	return semiMajorAxis >= h.minAxis && semiMajorAxis <= h.maxAxis
+}
+
+// binaryHost returns the inner binary as the host of circumbinary planets
+// between minAxis and maxAxis. The primary stands in for the pair with the
+// combined mass, so that orbital periods follow both stars, but keeps its
+// own radius for transits. The planets receive the combined luminosity at
+// the luminosity-weighted effective temperature.
+func binaryHost(primary, secondary models.Star, minAxis, maxAxis float64) planetHost {
+	star := primary
+	star.Mass += secondary.Mass
+	host := starHost(star, OrbitTypeP, minAxis, maxAxis)
+	host.luminosity = primary.Luminosity + secondary.Luminosity
+	host.temperature = int32(math.Round((primary.Luminosity*float64(primary.Temperature) +
+		secondary.Luminosity*float64(secondary.Temperature)) / host.luminosity))
+	return host
+}
+
+// multipleHosts returns the hosts of a multiple system: S-type orbits
+// around the primary and the secondary, and P-type orbits around both. In
+// triples, circumbinary orbits must also be stable against the tertiary,
+// which hosts no planets.
+func multipleHosts(system models.StarSystem, primary models.Star, companions []models.Star) []planetHost {
+	secondary := companions[0]
+	mu := secondary.Mass / (primary.Mass + secondary.Mass)
+	a, e := system.Separation, system.Eccentricity
+
+	hosts := []planetHost{
+		starHost(primary, OrbitTypeS, 0, sTypeLimit(a, e, mu)),
+		starHost(secondary, OrbitTypeS, 0, sTypeLimit(a, e, 1-mu)),
+		binaryHost(primary, secondary, pTypeLimit(a, e, mu), math.Inf(1)),
+	}
+	if system.Multiplicity > 2 {
+		outerMu := system.OuterMassRatio / (1 + system.OuterMassRatio)
+		hosts[2].maxAxis = sTypeLimit(system.OuterSeparation, system.OuterEccentricity, outerMu)
+	}
+	return hosts
+}
+
+// chooseHost returns the index of the host of an orbit with the given
+// semi-major axis, or -1 if no host holds it. Orbits that fit around the
+// primary move to the secondary in proportion to its mass, when it holds
+// them too.
+func chooseHost(r *rand.Rand, hosts []planetHost, semiMajorAxis float64) int {
+	if len(hosts) == 1 {
+		return 0
+	}
+
+	switch {
+	case hosts[0].holds(semiMajorAxis):
+		share := hosts[1].star.Mass / (hosts[0].star.Mass + hosts[1].star.Mass)
+		if r.Float64() < share && hosts[1].holds(semiMajorAxis) {
+			return 1
+		}
+		return 0
+	case hosts[2].holds(semiMajorAxis):
+		return 2
+	}
+	return -1
+}
+
+// rehome returns the host of an orbit at semiMajorAxis that no host holds,
+// together with a new semi-major axis drawn log-uniform within the nominal
+// orbit range narrowed to that host's stable range, or -1 if no host has
+// room. Orbits inside the circumbinary limit move to one of the stars,
+// chosen by mass, and others to the inner binary.
+func rehome(r *rand.Rand, hosts []planetHost, semiMajorAxis float64) (int, float64) {
+	order := []int{2, 0, 1}
+	if semiMajorAxis < hosts[2].minAxis {
+		order = []int{0, 1, 2}
+		if r.Float64() < hosts[1].star.Mass/(hosts[0].star.Mass+hosts[1].star.Mass) {
+			order = []int{1, 0, 2}
+		}
+	}
+
+	for _, h := range order {
+		min, max := hostRange(hosts[h], 0.01, 50.0)
+		if min < max {
+			return h, min * math.Pow(max/min, r.Float64())
+		}
+	}
+	return -1, 0
+}
+
+// hostGroup holds the planets and exoplanets orbiting one host
+type hostGroup struct {
+	host       planetHost
+	planets    []models.Planet
+	exoplanets []models.Exoplanet
+}
+
+// groupByHost assigns every planet and exoplanet drawn around the primary
+// to the host that holds its orbit. Orbits no host holds are redrawn around
+// another host, and dropped only if no host has room; it returns the groups
+// and the number dropped.
+func groupByHost(r *rand.Rand, hosts []planetHost, planets []models.Planet, exoplanets []models.Exoplanet) ([]hostGroup, int) {
+	groups := make([]hostGroup, len(hosts))
+	for i, host := range hosts {
+		groups[i].host = host
+	}
+
+	dropped := 0
+	for _, p := range planets {
+		h := chooseHost(r, hosts, p.SemiMajorAxis)
+		if h < 0 {
+			h, p.SemiMajorAxis = rehome(r, hosts, p.SemiMajorAxis)
+		}
+		if h < 0 {
+			dropped++
+			continue
+		}
+		host := hosts[h]
+		p.OrbitType = host.orbitType
+		p.StarID = host.star.ID
+		p.OrbitalPeriod = orbitalPeriod(host.star, p.SemiMajorAxis)
+		groups[h].planets = append(groups[h].planets, p)
+	}
+
+	for _, exo := range exoplanets {
+		h := chooseHost(r, hosts, exo.SemiMajorAxis)
+		if h < 0 {
+			h, exo.SemiMajorAxis = rehome(r, hosts, exo.SemiMajorAxis)
+		}
+		if h < 0 {
+			dropped++
+			continue
+		}
+		host := hosts[h]
+		exo.OrbitType = host.orbitType
+		exo.StarID = host.star.ID
+		exo.OrbitalPeriod = orbitalPeriod(host.star, exo.SemiMajorAxis)
+		groups[h].exoplanets = append(groups[h].exoplanets, exo)
+	}
+
+	return groups, dropped
+}
+
+// gatherGroups concatenates the planets and exoplanets of all groups and
+// points each group at its own part of the result, so that later changes
+// made through either are shared
+func gatherGroups(groups []hostGroup) ([]models.Planet, []models.Exoplanet) {
+	var planets []models.Planet
+	var exoplanets []models.Exoplanet
+	for _, g := range groups {
+		planets = append(planets, g.planets...)
+		exoplanets = append(exoplanets, g.exoplanets...)
+	}
+
+	n, m := 0, 0
+	for i := range groups {
+		g := &groups[i]
+		g.planets = planets[n : n+len(g.planets) : n+len(g.planets)]
+		g.exoplanets = exoplanets[m : m+len(g.exoplanets) : m+len(g.exoplanets)]
+		n += len(g.planets)
+		m += len(g.exoplanets)
+	}
+	return planets, exoplanets
+}
@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// Planet kinds, by mass
+const (
+	kindRocky = iota
+	kindIce
+	kindGiant
+)
+
+// Mass ranges in Earth masses of each planet kind
+var (
+	planetMassRanges    = [3][2]float64{{0.1, 5.0}, {5.0, 20.0}, {20.0, 1000.0}}
+	exoplanetMassRanges = [3][2]float64{{0.5, 5.0}, {5.0, 20.0}, {20.0, 500.0}}
+)
+
+// PlanetKinds holds one value for each kind of planet: rocky planets
+// (up to 5 Earth masses), ice giants (5-20) and gas giants (above 20)
+type PlanetKinds struct {
+	Rocky float64
+	Ice   float64
+	Giant float64
+}
+
+// OccurrenceConfig draws the number and kind of planets of each star from
+// occurrence rates that depend on the host's spectral class and metallicity
+type OccurrenceConfig struct {
+	// Rates is the mean number of planets of each kind per star at solar
+	// metallicity, keyed by the spectral class letter that starts
+	// SpectralType. Nil uses DefaultOccurrenceRates.
+	Rates map[string]PlanetKinds
+
+	// MetallicityExponents scale the rate of each kind by 10^(exponent *
+	// [Fe/H]). Nil uses DefaultMetallicityExponents.
+	MetallicityExponents *PlanetKinds
+}
+
+// DefaultOccurrenceRates are the mean planets per star of each spectral
+// class. Small planets are most common around M dwarfs (Mulders et al.
+// 2015) and giant planets become more common with stellar mass up to A
+// stars (Johnson et al. 2010).
+var DefaultOccurrenceRates = map[string]PlanetKinds{
+	"O": {Rocky: 0.1, Ice: 0.1, Giant: 0.05},
+	"B": {Rocky: 0.2, Ice: 0.2, Giant: 0.1},
+	"A": {Rocky: 0.4, Ice: 0.4, Giant: 0.2},
+	"F": {Rocky: 0.7, Ice: 0.7, Giant: 0.14},
+	"G": {Rocky: 1.0, Ice: 0.9, Giant: 0.1},
+	"K": {Rocky: 1.6, Ice: 0.8, Giant: 0.06},
+	"M": {Rocky: 2.5, Ice: 0.5, Giant: 0.03},
+	"D": {Rocky: 0.1, Ice: 0.05, Giant: 0.05},
+	"L": {Rocky: 0.5, Ice: 0.1},
+	"T": {Rocky: 0.3, Ice: 0.05},
+	"Y": {Rocky: 0.2},
+}
+
+// DefaultMetallicityExponents make giant planets more common around
+// metal-rich stars, P ∝ 10^(2 [Fe/H]) (Fischer & Valenti 2005), while small
+// planets occur at all metallicities (Buchhave et al. 2012)
+var DefaultMetallicityExponents = PlanetKinds{Giant: 2.0}
+
+// rates returns the configured occurrence rates or the defaults
+func (cfg OccurrenceConfig) rates() map[string]PlanetKinds {
+	if cfg.Rates == nil {
+		return DefaultOccurrenceRates
+	}
+	return cfg.Rates
+}
+
+// metallicityExponents returns the configured exponents or the defaults
+func (cfg OccurrenceConfig) metallicityExponents() PlanetKinds {
+	if cfg.MetallicityExponents == nil {
+		return DefaultMetallicityExponents
+	}
+	return *cfg.MetallicityExponents
+}
+
+// occurrenceRates returns the mean number of planets of each kind around
+// star, indexed by kind, or nil without occurrence rates
+func occurrenceRates(cfg Config, star models.Star) []float64 {
+	if cfg.Occurrence == nil {
+		return nil
+	}
+
+	rate := cfg.Occurrence.rates()[star.SpectralType[:1]]
+	exponent := cfg.Occurrence.metallicityExponents()
+	return []float64{
+		rate.Rocky * math.Pow(10, exponent.Rocky*star.Metallicity),
+		rate.Ice * math.Pow(10, exponent.Ice*star.Metallicity),
+		rate.Giant * math.Pow(10, exponent.Giant*star.Metallicity),
+	}
+}
+
+// planetCount draws the number of planets of a star, up to limit. With
+// occurrence rates it is Poisson distributed around their sum; otherwise
+// it is uniform.
+func planetCount(r *rand.Rand, rates []float64, limit int) int {
+	if rates == nil {
+		return r.Intn(limit + 1)
+	}
+
+	total := 0.0
+	for _, rate := range rates {
+		total += rate
+	}
+	return min(poisson(r, total), limit)
+}
+
+// planetKind draws the kind of a planet in proportion to the occurrence
+// rates, or with fixed fractions (30% rocky, 30% ice giants, 40% gas
+// giants) without them
+func planetKind(r *rand.Rand, rates []float64) int {
+	if rates != nil {
+		return weightedChoice(r, rates)
+	}
+
+	switch planetType := r.Float64(); {
+	case planetType < 0.3:
+		return kindRocky
+	case planetType < 0.6:
+		return kindIce
+	default:
+		return kindGiant
+	}
+}
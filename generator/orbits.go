@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// referenceEpoch is the epoch of the generated mean anomalies, J2000.0 as a
+// Julian Date
+const referenceEpoch = 2451545.0
+
+// mutualInclinationScale is the Rayleigh scale in degrees of the tilt of
+// each orbit relative to its system's mean orbital plane, matching the
+// near-coplanar Kepler multi-planet systems (Fabrycky et al. 2014)
+const mutualInclinationScale = 2.0
+
+// orbitOrientation holds the angular Keplerian elements of an orbit in degrees
+type orbitOrientation struct {
+	inclination, node, periapsis, meanAnomaly float64
+}
+
+// orientOrbits sets the inclination, ascending node, argument of periapsis,
+// mean anomaly and epoch of every planet and exoplanet of a system. Angles
+// are relative to the sky plane, the system's mean plane has an isotropic
+// orientation, and each orbit is tilted slightly away from it.
+func orientOrbits(r *rand.Rand, planets []models.Planet, exoplanets []models.Exoplanet) {
+	// Isotropic mean plane: cos i uniform in [-1, 1], node uniform
+	inclination := math.Acos(randFloat(r, -1, 1))
+	node := randFloat(r, 0, 2*math.Pi)
+	sinI, cosI := math.Sincos(inclination)
+	sinNode, cosNode := math.Sincos(node)
+	normal := [3]float64{sinI * sinNode, -sinI * cosNode, cosI}
+
+	for i := range planets {
+		o := orientOrbit(r, normal)
+		planets[i].Inclination = o.inclination
+		planets[i].LongitudeOfAscendingNode = o.node
+		planets[i].ArgumentOfPeriapsis = o.periapsis
+		planets[i].MeanAnomaly = o.meanAnomaly
+		planets[i].Epoch = referenceEpoch
+	}
+
+	for i := range exoplanets {
+		o := orientOrbit(r, normal)
+		exoplanets[i].Inclination = o.inclination
+		exoplanets[i].LongitudeOfAscendingNode = o.node
+		exoplanets[i].ArgumentOfPeriapsis = o.periapsis
+		exoplanets[i].MeanAnomaly = o.meanAnomaly
+		exoplanets[i].Epoch = referenceEpoch
+	}
+}
+
+// orientOrbit tilts the system plane with the given unit normal by a
+// Rayleigh-distributed mutual inclination in a random direction and draws
+// the in-plane angles uniformly
+func orientOrbit(r *rand.Rand, normal [3]float64) orbitOrientation {
+	// Two unit vectors perpendicular to the normal
+	u := [3]float64{1, 0, 0}
+	if math.Abs(normal[0]) > 0.9 {
+		u = [3]float64{0, 1, 0}
+	}
+	u = normalize(cross(normal, u))
+	v := cross(normal, u)
+
+	tilt := mutualInclinationScale * math.Pi / 180 * math.Sqrt(-2*math.Log(1-r.Float64()))
+	direction := randFloat(r, 0, 2*math.Pi)
+	sinT, cosT := math.Sincos(tilt)
+	sinD, cosD := math.Sincos(direction)
+
+	var n [3]float64
+	for k := range n {
+		n[k] = cosT*normal[k] + sinT*(cosD*u[k]+sinD*v[k])
+	}
+
+	node := math.Atan2(n[0], -n[1])
+	if node < 0 {
+		node += 2 * math.Pi
+	}
+
+	return orbitOrientation{
+		inclination: degrees(math.Acos(math.Max(-1, math.Min(1, n[2])))),
+		node:        degrees(node),
+		periapsis:   randFloat(r, 0, 360),
+		meanAnomaly: randFloat(r, 0, 360),
+	}
+}
+
+// cross returns the cross product a × b
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+// normalize scales v to unit length
+func normalize(v [3]float64) [3]float64 {
+	length := math.Sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2])
+	return [3]float64{v[0] / length, v[1] / length, v[2] / length}
+}
+
+// degrees converts radians to degrees
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
@@ -0,0 +1,8 @@
+package generator
+
+// Physical constants and unit conversions used by the generator
+const (
+	solarRadiusAU    = 0.00465047 // Solar radius in AU
+	solarTemperature = 5772.0     // Solar effective temperature in Kelvin
+	solarLogG        = 4.438      // Solar surface gravity, log g in cgs
+)
@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// RVConfig sets up the radial-velocity observations generated for exoplanets
+// detected by radial velocity. A zero Observations or Baseline takes the
+// default.
+type RVConfig struct {
+	Observations int           // Number of observations per host star (default 50)
+	Baseline     time.Duration // Time span of the observations (default 3 years)
+	Error        float64       // Instrumental error, 1 sigma in m/s (0 for none)
+	Jitter       float64       // Stellar jitter added to the noise in m/s (0 for none)
+}
+
+// Radial-velocity defaults, roughly a few seasons of a precise spectrograph
+const (
+	defaultRVObservations = 50
+	defaultRVBaseline     = 3 * 365 * 24 * time.Hour
+)
+
+// Semi-amplitude of a Jupiter-mass planet on a circular one-year edge-on
+// orbit around a one-solar-mass star, in m/s
+const jupiterRVAmplitude = 28.4329
+
+// jupiterMassEarth is one Jupiter mass in Earth masses
+const jupiterMassEarth = 317.828
+
+// generateRVObservations returns the radial-velocity observations of a host
+// star with exoplanets detected by radial velocity. The star is observed at
+// random times over the baseline starting at the reference epoch; every
+// observation measures the summed signal of those exoplanets.
+func generateRVObservations(r *rand.Rand, cfg RVConfig, star models.Star, exoplanets []models.Exoplanet) []models.RVObservation {
+	var detected []models.Exoplanet
+	for _, exo := range exoplanets {
+		if exo.DetectionMethod == DetectionRV {
+			detected = append(detected, exo)
+		}
+	}
+	if len(detected) == 0 {
+		return nil
+	}
+
+	if cfg.Observations <= 0 {
+		cfg.Observations = defaultRVObservations
+	}
+	if cfg.Baseline <= 0 {
+		cfg.Baseline = defaultRVBaseline
+	}
+	baseline := cfg.Baseline.Hours() / 24
+
+	times := make([]float64, cfg.Observations)
+	for i := range times {
+		times[i] = referenceEpoch + r.Float64()*baseline
+	}
+	sort.Float64s(times)
+
+	// Jitter is unmodelled stellar noise, so it is not part of the error bar
+	sigma := math.Hypot(cfg.Error, cfg.Jitter)
+
+	observations := make([]models.RVObservation, 0, len(times)*len(detected))
+	for _, jd := range times {
+		signals := make([]float64, len(detected))
+		total := 0.0
+		for i, exo := range detected {
+			signals[i] = radialVelocity(star, exo, jd)
+			total += signals[i]
+		}
+		measured := total + sigma*r.NormFloat64()
+
+		for i, exo := range detected {
+			observations = append(observations, models.RVObservation{
+				ExoplanetID:    exo.ID,
+				StarID:         star.ID,
+				Time:           jd,
+				RadialVelocity: measured,
+				Error:          cfg.Error,
+				Signal:         signals[i],
+			})
+		}
+	}
+	return observations
+}
+
+// rvSemiAmplitude returns the radial-velocity semi-amplitude K in m/s that
+// exo induces on star
+func rvSemiAmplitude(star models.Star, exo models.Exoplanet) float64 {
+	sinI := math.Sin(exo.Inclination * math.Pi / 180)
+	totalMass := star.Mass + exo.Mass*earthMassSolar
+	e := exo.Eccentricity
+
+	return jupiterRVAmplitude * exo.Mass * sinI / jupiterMassEarth *
+		math.Pow(totalMass, -2.0/3) *
+		math.Pow(exo.OrbitalPeriod/365.25, -1.0/3) /
+		math.Sqrt(1-e*e)
+}
+
+// radialVelocity returns the velocity of star away from the observer, in
+// m/s, induced by exo at Julian Date jd
+func radialVelocity(star models.Star, exo models.Exoplanet, jd float64) float64 {
+	e := exo.Eccentricity
+	meanAnomaly := math.Mod((exo.MeanAnomaly*math.Pi/180)+2*math.Pi/exo.OrbitalPeriod*(jd-exo.Epoch), 2*math.Pi)
+	E := solveKepler(meanAnomaly, e)
+	trueAnomaly := 2 * math.Atan2(math.Sqrt(1+e)*math.Sin(E/2), math.Sqrt(1-e)*math.Cos(E/2))
+
+	// The planet's argument of periapsis is measured with Z towards the
+	// observer, so its motion towards us is the star's motion away from us
+	w := exo.ArgumentOfPeriapsis * math.Pi / 180
+	return rvSemiAmplitude(star, exo) * (math.Cos(w+trueAnomaly) + e*math.Cos(w))
+}
@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Star parameter models
+const (
+	StarModelUniform = "uniform" // Mass, radius and temperature drawn independently per class
+	StarModelMass    = "mass"    // Radius and temperature derived from mass on the main sequence
+)
+
+// Main-sequence temperature limits applied to derived temperatures, matching
+// the coolest M and hottest O entries of spectralTypes
+const (
+	minMainSequenceTemp = 2400
+	maxMainSequenceTemp = 50000
+)
+
+// mainSequenceLuminosity returns the luminosity in solar units of a
+// main-sequence star of the given mass in solar masses, using the piecewise
+// mass-luminosity relation of Eker et al. (2018)
+func mainSequenceLuminosity(mass float64) float64 {
+	logM := math.Log10(mass)
+
+	var logL float64
+	switch {
+	case mass <= 0.45:
+		logL = 2.028*logM - 0.976
+	case mass <= 0.72:
+		logL = 4.572*logM - 0.102
+	case mass <= 1.05:
+		logL = 5.743*logM - 0.007
+	case mass <= 2.40:
+		logL = 4.329*logM + 0.010
+	case mass <= 7.0:
+		logL = 3.967*logM + 0.093
+	default:
+		logL = 2.865*logM + 1.105
+	}
+
+	return math.Pow(10, logL)
+}
+
+// mainSequenceRadius returns the radius in solar radii of a main-sequence
+// star of the given mass in solar masses (Demircan & Kahraman 1991)
+func mainSequenceRadius(mass float64) float64 {
+	if mass < 1.66 {
+		return 1.06 * math.Pow(mass, 0.945)
+	}
+	return 1.33 * math.Pow(mass, 0.555)
+}
+
+// effectiveTemperature returns the effective temperature in Kelvin of a
+// star from the Stefan-Boltzmann law, with luminosity and radius in solar units
+func effectiveTemperature(luminosity, radius float64) float64 {
+	return solarTemperature * math.Pow(luminosity/(radius*radius), 0.25)
+}
+
+// stellarLuminosity returns the luminosity in solar units of a star with the
+// given radius in solar radii and temperature in Kelvin
+func stellarLuminosity(radius float64, temperature int32) float64 {
+	t := float64(temperature) / solarTemperature
+	return radius * radius * t * t * t * t
+}
+
+// surfaceGravity returns log g in cgs units for mass and radius in solar units
+func surfaceGravity(mass, radius float64) float64 {
+	return solarLogG + math.Log10(mass) - 2*math.Log10(radius)
+}
+
+// mainSequenceLifetime returns the main-sequence lifetime in Gyr of a star
+// of the given mass in solar masses
+func mainSequenceLifetime(mass float64) float64 {
+	return 10.0 * math.Pow(mass, -2.5)
+}
+
+// mainSequenceParameters derives radius and temperature from mass using the
+// main-sequence relations, with log-normal scatter of the given fractional
+// width applied to luminosity and radius
+func mainSequenceParameters(r *rand.Rand, mass, scatter float64) (float64, int32) {
+	luminosity := mainSequenceLuminosity(mass)
+	radius := mainSequenceRadius(mass)
+	if scatter > 0 {
+		luminosity *= math.Exp(r.NormFloat64() * scatter)
+		radius *= math.Exp(r.NormFloat64() * scatter)
+	}
+
+	temperature := effectiveTemperature(luminosity, radius)
+	temperature = math.Max(minMainSequenceTemp, math.Min(maxMainSequenceTemp, temperature))
+
+	return radius, int32(math.Round(temperature))
+}
+
+// mainSequenceClassForTemperature returns the main-sequence spectral class
+// whose temperature range contains the given temperature
+func mainSequenceClassForTemperature(temperature int32) spectralClass {
+	for _, sc := range spectralTypes {
+		if temperature >= sc.tempRange[0] {
+			return sc
+		}
+	}
+	return spectralTypes[len(spectralTypes)-1]
+}
+
+// subclassForTemperature places a temperature on the 0-9 subclass scale of
+// a spectral class, 0 being the hottest
+func subclassForTemperature(sc spectralClass, temperature int32) int {
+	span := float64(sc.tempRange[1] - sc.tempRange[0])
+	subclass := int(10 * float64(sc.tempRange[1]-temperature) / span)
+	if subclass < 0 {
+		return 0
+	}
+	if subclass > 9 {
+		return 9
+	}
+	return subclass
+}
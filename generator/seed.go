@@ -0,0 +1,65 @@
+package generator
+
+import (
+	"math/rand"
+)
+
+// splitmix64 is the SplitMix64 finalizer. It maps consecutive inputs to
+// statistically independent 64-bit outputs.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// splitMixSource is a rand.Source64 backed by the SplitMix64 generator.
+// Its state is a single word, so creating one per star is cheap.
+type splitMixSource struct {
+	state uint64
+}
+
+func (s *splitMixSource) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+func (s *splitMixSource) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func (s *splitMixSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// systemSeed derives the seed for the star at index (0-based) from the
+// dataset seed. Every star gets its own independent random stream, so a
+// star's system does not depend on any other star having been generated.
+func systemSeed(seed int64, index int) uint64 {
+	return splitmix64(splitmix64(uint64(seed)) ^ uint64(index))
+}
+
+// systemRand returns the random stream for the star at index (0-based)
+func systemRand(seed int64, index int) *rand.Rand {
+	return rand.New(&splitMixSource{state: systemSeed(seed, index)})
+}
+
+// Auxiliary random streams of a star. Optional outputs draw from their own
+// stream, so enabling them does not change the rest of the system. Moons
+// have their own stream so that planets do not depend on them.
+const (
+	streamLightCurves uint64 = iota + 1
+	streamRadialVelocities
+	streamSurveys
+	streamCompositions
+	streamMoons
+)
+
+// auxRand returns the auxiliary random stream with the given id for the
+// star at index (0-based)
+func auxRand(seed int64, index int, stream uint64) *rand.Rand {
+	return rand.New(&splitMixSource{state: splitmix64(systemSeed(seed, index) ^ splitmix64(stream))})
+}
@@ -0,0 +1,155 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// Spatial models for star positions and kinematics
+const (
+	SpatialUniform   = "uniform"    // Uniform density in a sphere around the Sun
+	SpatialThinDisk  = "thin-disk"  // Exponential Galactic thin disk
+	SpatialThickDisk = "thick-disk" // Exponential Galactic thick disk
+)
+
+// defaultMaxDistance is the radius in parsecs of the generated volume when
+// Config.MaxDistance is not set (about 10000 light years)
+const defaultMaxDistance = 3000.0
+
+// minDistance keeps stars clear of the Sun, in parsecs
+const minDistance = 1.0
+
+// lightYearsPerParsec converts parsecs to light years
+const lightYearsPerParsec = 3.26156
+
+// Solar position and motion (Bland-Hawthorn & Gerhard 2016, Schönrich et al. 2010)
+const (
+	sunGalactocentricRadius = 8178.0 // pc
+	sunHeightAbovePlane     = 20.8   // pc
+)
+
+var solarMotion = [3]float64{11.1, 12.24, 7.25} // U, V, W relative to the LSR in km/s
+
+// auYearInKmPerSecond is the velocity of 1 AU per year in km/s, relating
+// tangential velocity to proper motion and distance
+const auYearInKmPerSecond = 4.740470
+
+// spatialModel describes the density and velocity distribution of a stellar
+// population
+type spatialModel struct {
+	scaleHeight float64    // Exponential scale height in pc (0 for uniform density)
+	scaleLength float64    // Exponential scale length in pc (0 for uniform density)
+	dispersion  [3]float64 // Velocity dispersion in U, V, W in km/s
+	drift       float64    // Asymmetric drift (mean V lag behind the LSR) in km/s
+}
+
+var spatialModels = map[string]spatialModel{
+	SpatialUniform:   {dispersion: [3]float64{30, 30, 30}},
+	SpatialThinDisk:  {scaleHeight: 300, scaleLength: 2600, dispersion: [3]float64{35, 20, 16}, drift: 15},
+	SpatialThickDisk: {scaleHeight: 900, scaleLength: 3600, dispersion: [3]float64{67, 38, 35}, drift: 46},
+}
+
+// galacticToEquatorial rotates heliocentric Galactic cartesian coordinates
+// (x towards the Galactic centre, z towards the north Galactic pole) into
+// ICRS equatorial coordinates. It is the transpose of the Hipparcos matrix.
+var galacticToEquatorial = [3][3]float64{
+	{-0.0548755604162154, 0.4941094278755837, -0.8676661490190047},
+	{-0.8734370902348850, -0.4448296299600112, -0.1980763734312015},
+	{-0.4838350155487132, 0.7469822444972189, 0.4559837761750669},
+}
+
+// placeStar sets the sky position, distance, parallax, proper motion and
+// radial velocity of a star according to the configured spatial model
+func placeStar(r *rand.Rand, cfg Config, star *models.Star) {
+	model, ok := spatialModels[cfg.SpatialModel]
+	if !ok {
+		model = spatialModels[SpatialUniform]
+	}
+	maxDistance := cfg.MaxDistance
+	if maxDistance <= 0 {
+		maxDistance = defaultMaxDistance
+	}
+
+	pos := samplePosition(r, model, maxDistance)
+	distance := math.Sqrt(pos[0]*pos[0] + pos[1]*pos[1] + pos[2]*pos[2])
+
+	// Heliocentric velocity: the star's motion relative to the LSR minus the Sun's
+	var vel [3]float64
+	for i := range vel {
+		vel[i] = model.dispersion[i]*r.NormFloat64() - solarMotion[i]
+	}
+	vel[1] -= model.drift
+
+	eqPos := rotate(galacticToEquatorial, pos)
+	eqVel := rotate(galacticToEquatorial, vel)
+
+	ra := math.Atan2(eqPos[1], eqPos[0])
+	if ra < 0 {
+		ra += 2 * math.Pi
+	}
+	dec := math.Asin(eqPos[2] / distance)
+
+	// Project the velocity onto the line of sight and the east/north directions
+	sinRA, cosRA := math.Sincos(ra)
+	sinDec, cosDec := math.Sincos(dec)
+	vEast := -sinRA*eqVel[0] + cosRA*eqVel[1]
+	vNorth := -sinDec*cosRA*eqVel[0] - sinDec*sinRA*eqVel[1] + cosDec*eqVel[2]
+	vRadial := cosDec*cosRA*eqVel[0] + cosDec*sinRA*eqVel[1] + sinDec*eqVel[2]
+
+	star.RA = degrees(ra)
+	star.Dec = degrees(dec)
+	star.Distance = distance
+	star.Parallax = 1000.0 / distance
+	star.PMRA = 1000.0 * vEast / (auYearInKmPerSecond * distance)
+	star.PMDec = 1000.0 * vNorth / (auYearInKmPerSecond * distance)
+	star.RadialVelocity = vRadial
+}
+
+// samplePosition draws a heliocentric Galactic position in parsecs within
+// maxDistance of the Sun. Disk models use rejection sampling against an
+// exponential density in Galactocentric radius and height.
+func samplePosition(r *rand.Rand, model spatialModel, maxDistance float64) [3]float64 {
+	const maxAttempts = 10000
+
+	var pos [3]float64
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		pos = uniformInSphere(r, maxDistance)
+		if model.scaleHeight == 0 {
+			return pos
+		}
+
+		// Density relative to its maximum within the sphere
+		radius := math.Hypot(sunGalactocentricRadius-pos[0], pos[1])
+		height := math.Abs(pos[2] + sunHeightAbovePlane)
+		density := math.Exp(-(radius-(sunGalactocentricRadius-maxDistance))/model.scaleLength) *
+			math.Exp(-height/model.scaleHeight)
+		if r.Float64() < density {
+			return pos
+		}
+	}
+
+	return pos
+}
+
+// uniformInSphere draws a point uniformly within a shell between minDistance
+// and maxDistance
+func uniformInSphere(r *rand.Rand, maxDistance float64) [3]float64 {
+	lo, hi := minDistance*minDistance*minDistance, maxDistance*maxDistance*maxDistance
+	distance := math.Cbrt(lo + r.Float64()*(hi-lo))
+
+	z := randFloat(r, -1, 1)
+	phi := randFloat(r, 0, 2*math.Pi)
+	s := math.Sqrt(1 - z*z)
+	return [3]float64{distance * s * math.Cos(phi), distance * s * math.Sin(phi), distance * z}
+}
+
+// rotate applies a rotation matrix to a vector
+func rotate(m [3][3]float64, v [3]float64) [3]float64 {
+	var out [3]float64
+	for i := range out {
+		out[i] = m[i][0]*v[0] + m[i][1]*v[1] + m[i][2]*v[2]
+	}
+	return out
+}
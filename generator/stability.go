@@ -0,0 +1,251 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// Orbit spacing modes
+const (
+	OrbitSpacingHill        = "hill"         // Neighbours separated by a number of mutual Hill radii
+	OrbitSpacingPeriodRatio = "period-ratio" // Neighbours separated by a period ratio
+)
+
+// minHillSpacing is the separation, in mutual Hill radii, that neighbouring
+// orbits need for long-term stability of multi-planet systems
+// (Chambers et al. 1996; Smith & Lissauer 2009)
+const minHillSpacing = 8.0
+
+// Ranges the spacing between neighbouring orbits is drawn from. The Hill
+// range follows the separations of Kepler multi-planet systems.
+var (
+	hillSpacingRange = [2]float64{10.0, 25.0}
+	periodRatioRange = [2]float64{1.3, 2.5}
+)
+
+// crossingClearance is the margin kept between the apoapsis of an orbit and
+// the periapsis of the next
+const crossingClearance = 1.05
+
+// earthMassSolar is one Earth mass in solar masses
+const earthMassSolar = 3.003e-6
+
+// orbit holds the properties of a planet or exoplanet that decide stability
+type orbit struct {
+	semiMajorAxis float64 // AU
+	eccentricity  float64
+	mass          float64 // Earth masses
+}
+
+// mutualHillRadius returns the mutual Hill radius in AU of two neighbouring
+// orbits around a star of the given mass in solar masses
+func mutualHillRadius(starMass float64, inner, outer orbit) float64 {
+	h := math.Cbrt((inner.mass + outer.mass) * earthMassSolar / (3 * starMass))
+	return h * (inner.semiMajorAxis + outer.semiMajorAxis) / 2
+}
+
+// stableOrbits reports whether orbits sorted by semi-major axis are separated
+// by at least minHillSpacing mutual Hill radii and do not cross
+func stableOrbits(starMass float64, orbits []orbit) bool {
+	for i := 1; i < len(orbits); i++ {
+		inner, outer := orbits[i-1], orbits[i]
+		separation := outer.semiMajorAxis - inner.semiMajorAxis
+		if separation < minHillSpacing*mutualHillRadius(starMass, inner, outer) {
+			return false
+		}
+		if inner.semiMajorAxis*(1+inner.eccentricity) >= outer.semiMajorAxis*(1-outer.eccentricity) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsStable reports whether the planets of one system are dynamically
+// stable: neighbouring orbits must be separated by at least 8 mutual Hill
+// radii and must not cross. The stellar mass is derived from the planets'
+// periods and semi-major axes.
+func IsStable(planets []models.Planet) bool {
+	if len(planets) < 2 {
+		return true
+	}
+
+	sorted := make([]models.Planet, len(planets))
+	copy(sorted, planets)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].SemiMajorAxis < sorted[j].SemiMajorAxis })
+
+	// Kepler's third law in AU, years and solar masses
+	years := sorted[0].OrbitalPeriod / 365.25
+	starMass := sorted[0].SemiMajorAxis * sorted[0].SemiMajorAxis * sorted[0].SemiMajorAxis / (years * years)
+
+	orbits := make([]orbit, len(sorted))
+	for i, p := range sorted {
+		orbits[i] = orbit{semiMajorAxis: p.SemiMajorAxis, eccentricity: p.Eccentricity, mass: p.Mass}
+	}
+	return stableOrbits(starMass, orbits)
+}
+
+// spaceOrbits assigns new semi-major axes to orbits in order, innermost
+// first. The innermost orbit is log-uniform within the inner half (in log
+// space) of [minAxis, maxAxis]; each following orbit is placed at the
+// configured spacing from its inner neighbour, never closer than the
+// stability limit. It returns the number of orbits placed: the first orbit
+// that cannot be made stable, or that falls beyond maxAxis, is dropped
+// together with all orbits after it.
+func spaceOrbits(r *rand.Rand, cfg Config, starMass, minAxis, maxAxis float64, orbits []orbit) int {
+	if len(orbits) == 0 {
+		return 0
+	}
+	orbits[0].semiMajorAxis = minAxis * math.Pow(maxAxis/minAxis, 0.5*r.Float64())
+
+	for i := 1; i < len(orbits); i++ {
+		inner, outer := orbits[i-1], &orbits[i]
+
+		// Smallest axis that keeps the minimum Hill spacing
+		h := math.Cbrt((inner.mass + outer.mass) * earthMassSolar / (3 * starMass))
+		stable, ok := hillSpacedAxis(inner.semiMajorAxis, h, hillSpacingRange[0])
+		if !ok {
+			return i
+		}
+
+		var a float64
+		switch cfg.OrbitSpacing {
+		case OrbitSpacingPeriodRatio:
+			ratio := randFloat(r, periodRatioRange[0], periodRatioRange[1])
+			a = inner.semiMajorAxis * math.Pow(ratio, 2.0/3.0)
+		default:
+			a, ok = hillSpacedAxis(inner.semiMajorAxis, h, randFloat(r, hillSpacingRange[0], hillSpacingRange[1]))
+			if !ok {
+				a = stable
+			}
+		}
+
+		// Keep the orbits from crossing
+		crossing := crossingClearance * inner.semiMajorAxis * (1 + inner.eccentricity) / (1 - outer.eccentricity)
+		outer.semiMajorAxis = math.Max(a, math.Max(stable, crossing))
+		if outer.semiMajorAxis > maxAxis {
+			return i
+		}
+	}
+
+	return len(orbits)
+}
+
+// hillSpacedAxis returns the semi-major axis separated from inner by spacing
+// mutual Hill radii, where h is the reduced Hill factor of the pair. It
+// fails when the planets are too massive relative to the star for any
+// such orbit to exist.
+func hillSpacedAxis(inner, h, spacing float64) (float64, bool) {
+	k := spacing * h / 2
+	if k >= 1 {
+		return 0, false
+	}
+	return inner * (1 + k) / (1 - k), true
+}
+
+// hostRange returns the nominal orbit range [min, max] in AU around host,
+// narrowed to the range that is stable around it
+func hostRange(host planetHost, min, max float64) (float64, float64) {
+	min, max = orbitRange(host.star, min, max)
+	return math.Max(min, host.minAxis), math.Min(max, host.maxAxis)
+}
+
+// spaceBodies rebuilds the orbits of a host's planets and exoplanets from
+// the inside out as one system, so that they are also well separated from
+// each other. Planets and exoplanets each keep their order, so that they
+// are numbered from the inside out; the two lists are interleaved by
+// comparing the drawn semi-major axes of the next planet and exoplanet,
+// without sorting either. Bodies from the first one that cannot be placed
+// stably are dropped; it returns the remaining planets and exoplanets and
+// the number dropped.
+func spaceBodies(r *rand.Rand, cfg Config, host planetHost, planets []models.Planet, exoplanets []models.Exoplanet) ([]models.Planet, []models.Exoplanet, int) {
+	total := len(planets) + len(exoplanets)
+	minAxis, maxAxis := hostRange(host, 0.01, 50.0)
+	if minAxis >= maxAxis {
+		return planets[:0], exoplanets[:0], total
+	}
+
+	// isPlanet records whether each orbit belongs to a planet or exoplanet
+	orbits := make([]orbit, 0, total)
+	isPlanet := make([]bool, 0, total)
+	for i, j := 0, 0; i < len(planets) || j < len(exoplanets); {
+		if j == len(exoplanets) || (i < len(planets) && planets[i].SemiMajorAxis <= exoplanets[j].SemiMajorAxis) {
+			orbits = append(orbits, orbit{eccentricity: planets[i].Eccentricity, mass: planets[i].Mass})
+			isPlanet = append(isPlanet, true)
+			i++
+		} else {
+			orbits = append(orbits, orbit{eccentricity: exoplanets[j].Eccentricity, mass: exoplanets[j].Mass})
+			isPlanet = append(isPlanet, false)
+			j++
+		}
+	}
+
+	placed := spaceOrbits(r, cfg, host.star.Mass, minAxis, maxAxis, orbits)
+	numPlanets, numExoplanets := 0, 0
+	for k, o := range orbits[:placed] {
+		if isPlanet[k] {
+			planets[numPlanets].SemiMajorAxis = o.semiMajorAxis
+			planets[numPlanets].OrbitalPeriod = orbitalPeriod(host.star, o.semiMajorAxis)
+			numPlanets++
+		} else {
+			exoplanets[numExoplanets].SemiMajorAxis = o.semiMajorAxis
+			exoplanets[numExoplanets].OrbitalPeriod = orbitalPeriod(host.star, o.semiMajorAxis)
+			numExoplanets++
+		}
+	}
+	return planets[:numPlanets], exoplanets[:numExoplanets], total - placed
+}
+
+// pruneBodies keeps the orbits of a host's planets and exoplanets, as laid
+// out by an architecture template, and drops from the inside out every body
+// that is not stable against the nearest one kept inside it. It returns the
+// remaining planets and exoplanets and the number dropped.
+func pruneBodies(host planetHost, planets []models.Planet, exoplanets []models.Exoplanet) ([]models.Planet, []models.Exoplanet, int) {
+	type body struct {
+		orbit     orbit
+		exoplanet bool
+		index     int
+	}
+	bodies := make([]body, 0, len(planets)+len(exoplanets))
+	for i, p := range planets {
+		bodies = append(bodies, body{orbit: orbit{semiMajorAxis: p.SemiMajorAxis, eccentricity: p.Eccentricity, mass: p.Mass}, index: i})
+	}
+	for i, exo := range exoplanets {
+		bodies = append(bodies, body{orbit: orbit{semiMajorAxis: exo.SemiMajorAxis, eccentricity: exo.Eccentricity, mass: exo.Mass}, exoplanet: true, index: i})
+	}
+	sort.SliceStable(bodies, func(i, j int) bool { return bodies[i].orbit.semiMajorAxis < bodies[j].orbit.semiMajorAxis })
+
+	keepPlanet := make([]bool, len(planets))
+	keepExoplanet := make([]bool, len(exoplanets))
+	var kept []orbit
+	for _, b := range bodies {
+		if len(kept) > 0 && !stableOrbits(host.star.Mass, []orbit{kept[len(kept)-1], b.orbit}) {
+			continue
+		}
+		kept = append(kept, b.orbit)
+		if b.exoplanet {
+			keepExoplanet[b.index] = true
+		} else {
+			keepPlanet[b.index] = true
+		}
+	}
+
+	var n int
+	for i, p := range planets {
+		if keepPlanet[i] {
+			planets[n] = p
+			n++
+		}
+	}
+	planets = planets[:n]
+	n = 0
+	for i, exo := range exoplanets {
+		if keepExoplanet[i] {
+			exoplanets[n] = exo
+			n++
+		}
+	}
+	return planets, exoplanets[:n], len(bodies) - len(kept)
+}
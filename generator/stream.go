@@ -0,0 +1,121 @@
+package generator
+
+import (
+	"context"
+)
+
+// streamBuffer is the number of systems buffered ahead of the consumer.
+// It bounds memory use regardless of the number of stars generated.
+const streamBuffer = 64
+
+// Stream generates star systems and sends them on the returned channel in
+// star index order. Only stars in the configured shard are generated. The
+// channel is closed once all stars have been generated or ctx is cancelled.
+// Consumers that stop reading early must cancel ctx so the generating
+// goroutines can exit.
+//
+// With cfg.Workers > 1 systems are generated concurrently. Each star draws
+// from its own random stream derived from cfg.Seed and the star index, so the
+// sequence of systems is identical for any number of workers and matches the
+// data returned by GenerateAll.
+func Stream(ctx context.Context, cfg Config) <-chan System {
+	out := make(chan System, streamBuffer)
+
+	if cfg.Workers > 1 {
+		go streamParallel(ctx, cfg, out)
+		return out
+	}
+
+	go func() {
+		defer close(out)
+
+		start, end := cfg.shardRange()
+		for i := start; i < end; i++ {
+			select {
+			case out <- GenerateSystem(cfg, i):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// streamBatch is the number of consecutive stars handed to a worker at a
+// time, which keeps channel overhead small relative to generation work
+const streamBatch = 32
+
+// systemJob asks a worker to generate the stars in [start, end) into result
+type systemJob struct {
+	start, end int
+	result     chan<- []System
+}
+
+// streamParallel fans generation out to cfg.Workers goroutines and sends the
+// results to out in star index order
+func streamParallel(ctx context.Context, cfg Config, out chan<- System) {
+	defer close(out)
+
+	// pending holds one result slot per dispatched batch, in index order.
+	// Its capacity bounds how far workers may run ahead of the consumer.
+	pending := make(chan chan []System, cfg.Workers*2)
+	jobs := make(chan systemJob, cfg.Workers)
+
+	// Dispatch batches of star indices
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+
+		first, last := cfg.shardRange()
+		for start := first; start < last; start += streamBatch {
+			end := start + streamBatch
+			if end > last {
+				end = last
+			}
+
+			slot := make(chan []System, 1)
+			select {
+			case pending <- slot:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- systemJob{start: start, end: end, result: slot}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Generate systems
+	for w := 0; w < cfg.Workers; w++ {
+		go func() {
+			for job := range jobs {
+				batch := make([]System, 0, job.end-job.start)
+				for i := job.start; i < job.end; i++ {
+					batch = append(batch, GenerateSystem(cfg, i))
+				}
+				job.result <- batch
+			}
+		}()
+	}
+
+	// Collect results in index order
+	for slot := range pending {
+		var batch []System
+		select {
+		case batch = <-slot:
+		case <-ctx.Done():
+			return
+		}
+
+		for _, system := range batch {
+			select {
+			case out <- system:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
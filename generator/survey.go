@@ -0,0 +1,157 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"djdees/synthetic_stellar_data/models"
+)
+
+// SurveyConfig sets up the survey sensitivity models that decide which
+// exoplanets of the true population enter the observed catalog. Zero-valued
+// fields take the defaults.
+type SurveyConfig struct {
+	// Transit survey: photometric noise in ppm over one hour, the observing
+	// baseline and the signal-to-noise threshold (defaults 100 ppm, 4 years, 7.1)
+	TransitNoise    float64
+	TransitBaseline time.Duration
+	MinTransitSNR   float64
+
+	// Radial-velocity survey: precision per observation in m/s, number of
+	// observations and the signal-to-noise threshold (defaults 1 m/s, 50, 10)
+	RVPrecision    float64
+	RVObservations int
+	MinRVSNR       float64
+
+	// Imaging survey: faintest planet/star flux ratio and the inner working
+	// angle in arcsec (defaults 1e-6 and 0.2")
+	ImagingContrast   float64
+	ImagingInnerAngle float64
+}
+
+// Survey defaults, roughly Kepler, HARPS and SPHERE
+const (
+	defaultTransitNoise      = 100.0
+	defaultTransitBaseline   = 4 * 365 * 24 * time.Hour
+	defaultMinTransitSNR     = 7.1
+	defaultRVPrecision       = 1.0
+	defaultRVSurveySize      = 50
+	defaultMinRVSNR          = 10.0
+	defaultImagingContrast   = 1e-6
+	defaultImagingInnerAngle = 0.2
+)
+
+// withDefaults returns cfg with zero-valued fields set to the defaults
+func (cfg SurveyConfig) withDefaults() SurveyConfig {
+	if cfg.TransitNoise <= 0 {
+		cfg.TransitNoise = defaultTransitNoise
+	}
+	if cfg.TransitBaseline <= 0 {
+		cfg.TransitBaseline = defaultTransitBaseline
+	}
+	if cfg.MinTransitSNR <= 0 {
+		cfg.MinTransitSNR = defaultMinTransitSNR
+	}
+	if cfg.RVPrecision <= 0 {
+		cfg.RVPrecision = defaultRVPrecision
+	}
+	if cfg.RVObservations <= 0 {
+		cfg.RVObservations = defaultRVSurveySize
+	}
+	if cfg.MinRVSNR <= 0 {
+		cfg.MinRVSNR = defaultMinRVSNR
+	}
+	if cfg.ImagingContrast <= 0 {
+		cfg.ImagingContrast = defaultImagingContrast
+	}
+	if cfg.ImagingInnerAngle <= 0 {
+		cfg.ImagingInnerAngle = defaultImagingInnerAngle
+	}
+	return cfg
+}
+
+// Cooling of a young giant planet, L = L0 (M/MJup)^a (t/0.1 Gyr)^b, after
+// the hot-start models of Burrows et al. (1997)
+const (
+	youngJupiterLuminosity = 3e-7 // Solar luminosities at 0.1 Gyr
+	coolingMassExponent    = 2.64
+	coolingAgeExponent     = -1.3
+)
+
+// applySurveys observes every exoplanet with the survey of its detection
+// method. Planets the survey misses stay in the true population but are
+// marked as not detected and lose their detection method and discovery year.
+// Microlensing, astrometry and transit timing have no sensitivity model and
+// detect a planet with the probability given by its detectability.
+func applySurveys(r *rand.Rand, cfg SurveyConfig, star models.Star, exoplanets []models.Exoplanet) {
+	cfg = cfg.withDefaults()
+	for i := range exoplanets {
+		exo := &exoplanets[i]
+
+		var detected bool
+		switch exo.DetectionMethod {
+		case DetectionTransit:
+			detected = transitSNR(cfg, star, *exo) >= cfg.MinTransitSNR
+		case DetectionRV:
+			detected = rvSNR(cfg, star, *exo) >= cfg.MinRVSNR
+		case DetectionImaging:
+			detected = imagingDetected(cfg, star, *exo)
+		default:
+			for _, method := range detectionMethods {
+				if method.name == exo.DetectionMethod {
+					detected = r.Float64() < method.detectability(star, *exo, len(exoplanets)-1)
+				}
+			}
+		}
+
+		if !detected {
+			exo.Detected = false
+			exo.DetectionMethod = ""
+			exo.DiscoveryYear = 0
+		}
+	}
+}
+
+// transitSNR returns the signal-to-noise ratio of all transits of exo within
+// the survey baseline
+func transitSNR(cfg SurveyConfig, star models.Star, exo models.Exoplanet) float64 {
+	starRadius := star.Radius * solarRadiusAU
+	k := exo.Radius * earthRadiusAU / starRadius
+
+	// Impact parameter and duration (hours) of the transit
+	e := exo.Eccentricity
+	sinW := math.Sin(exo.ArgumentOfPeriapsis * math.Pi / 180)
+	distance := exo.SemiMajorAxis * (1 - e*e) / (1 + e*sinW)
+	b := distance * math.Abs(math.Cos(exo.Inclination*math.Pi/180)) / starRadius
+	if b >= 1+k {
+		return 0
+	}
+	chord := math.Sqrt((1+k)*(1+k) - b*b)
+	duration := exo.OrbitalPeriod * 24 / math.Pi * math.Asin(math.Min(1, starRadius*chord/exo.SemiMajorAxis)) *
+		math.Sqrt(1-e*e) / (1 + e*sinW)
+
+	transits := math.Floor(cfg.TransitBaseline.Hours() / 24 / exo.OrbitalPeriod)
+	depth := k * k
+	return depth / (cfg.TransitNoise * 1e-6) * math.Sqrt(transits*duration)
+}
+
+// rvSNR returns the signal-to-noise ratio of the radial-velocity signal of
+// exo over the survey's observations
+func rvSNR(cfg SurveyConfig, star models.Star, exo models.Exoplanet) float64 {
+	return rvSemiAmplitude(star, exo) / cfg.RVPrecision * math.Sqrt(float64(cfg.RVObservations)/2)
+}
+
+// imagingDetected reports whether exo is both resolved from its star and
+// bright enough to be seen next to it
+func imagingDetected(cfg SurveyConfig, star models.Star, exo models.Exoplanet) bool {
+	separation := exo.SemiMajorAxis / star.Distance // arcsec
+	if separation < cfg.ImagingInnerAngle {
+		return false
+	}
+
+	luminosity := youngJupiterLuminosity *
+		math.Pow(exo.Mass/jupiterMassEarth, coolingMassExponent) *
+		math.Pow(star.Age/0.1, coolingAgeExponent)
+	return luminosity/star.Luminosity >= cfg.ImagingContrast
+}
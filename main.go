@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"time"
 
 	"djdees/synthetic_stellar_data/config"
@@ -12,6 +14,18 @@ import (
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "explain":
+			runExplain(os.Args[2:])
+			return
+		case "ephemeris":
+			runEphemeris(os.Args[2:])
+			return
+		}
+	}
+
 	// Parse command-line flags
 	cfg := config.ParseFlags()
 
@@ -24,26 +38,55 @@ func main() {
 	fmt.Printf("Output Directory: %s\n", cfg.OutputDir)
 	fmt.Printf("Seed: %d\n", cfg.Seed)
 	fmt.Printf("Dry Run: %t\n", cfg.DryRun)
+	fmt.Printf("Workers: %d\n", cfg.Workers)
+	if cfg.PopulationFile != "" {
+		fmt.Printf("Population Model: %s\n", cfg.PopulationFile)
+	}
+	if cfg.LightCurves {
+		fmt.Printf("Light Curves: %.1f days every %v, %.0f ppm noise\n",
+			cfg.LightCurveDays, cfg.LightCurveCadence, cfg.LightCurveNoise)
+	}
+	if cfg.RVObservations > 0 {
+		fmt.Printf("RV Observations: %d over %.0f days, %.1f m/s error, %.1f m/s jitter\n",
+			cfg.RVObservations, cfg.RVDays, cfg.RVError, cfg.RVJitter)
+	}
+	if cfg.Compositions {
+		fmt.Println("Atmosphere Compositions: enabled")
+	}
+	if cfg.Designations {
+		fmt.Println("Catalog Designations: enabled")
+	}
+	if cfg.ShardCount > 1 {
+		fmt.Printf("Shard: %d of %d\n", cfg.ShardIndex, cfg.ShardCount)
+	}
 	fmt.Println()
 
 	// Create generator configuration
-	genCfg := generator.Config{
-		NumStars:       cfg.NumStars,
-		PlanetsPerStar: cfg.PlanetsPerStar,
-		ExoPerStar:     cfg.ExoPerStar,
-		Seed:           cfg.Seed,
+	genCfg, err := generatorConfig(cfg.GenerationFlags, cfg.Seed)
+	if err != nil {
+		log.Fatalf("Failed to load population model: %v", err)
 	}
+	genCfg.NumStars = cfg.NumStars
+	genCfg.Workers = cfg.Workers
+	genCfg.ShardIndex = cfg.ShardIndex
+	genCfg.ShardCount = cfg.ShardCount
 
-	// Generate data
-	fmt.Println("Generating stellar data...")
-	startTime := time.Now()
-	data := generator.GenerateAll(genCfg)
-	duration := time.Since(startTime)
-
-	fmt.Printf("Generated %d stars, %d planets, %d exoplanets in %v\n",
-		len(data.Stars), len(data.Planets), len(data.Exoplanets), duration)
+	// Stop generation cleanly on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
 	if cfg.DryRun {
+		fmt.Println("Generating stellar data...")
+		startTime := time.Now()
+		var stats generationStats
+		for system := range generator.Stream(ctx, genCfg) {
+			stats.add(system)
+		}
+		if err := ctx.Err(); err != nil {
+			log.Fatalf("Generation interrupted: %v", err)
+		}
+		fmt.Printf("Generated %d stars, %d planets, %d moons, %d exoplanets (%d detected) in %v\n",
+			stats.stars, stats.planets, stats.moons, stats.exoplanets, stats.detected, time.Since(startTime))
 		fmt.Println("\nDry run mode - no output written")
 		return
 	}
@@ -53,35 +96,204 @@ func main() {
 		log.Fatalf("Failed to create output directory: %v", err)
 	}
 
-	// Write output based on format
-	fmt.Printf("\nWriting output in %s format...\n", cfg.OutputFormat)
-	startTime = time.Now()
+	// Generate data and stream it into the selected output format
+	fmt.Printf("Generating stellar data and writing output in %s format...\n", cfg.OutputFormat)
+	startTime := time.Now()
+	var stats generationStats
+	systems := countSystems(ctx, generator.Stream(ctx, genCfg), &stats)
 
 	switch cfg.OutputFormat {
 	case "csv":
-		if err := writers.WriteCSV(data, cfg.OutputDir); err != nil {
+		if err := writers.WriteCSV(ctx, systems, cfg.OutputDir); err != nil {
 			log.Fatalf("Failed to write CSV: %v", err)
 		}
 	case "json":
-		if err := writers.WriteJSON(data, cfg.OutputDir); err != nil {
+		if err := writers.WriteJSON(ctx, systems, cfg.OutputDir); err != nil {
 			log.Fatalf("Failed to write JSON: %v", err)
 		}
 	case "parquet":
-		if err := writers.WriteParquet(data, cfg.OutputDir); err != nil {
+		if err := writers.WriteParquet(ctx, systems, cfg.OutputDir); err != nil {
 			log.Fatalf("Failed to write Parquet: %v", err)
 		}
 	case "cassandra":
 		if cfg.ConfigFile == "" {
 			log.Fatal("Cassandra output requires --config flag with YAML configuration file")
 		}
-		if err := writers.WriteToCassandra(data, cfg.ConfigFile); err != nil {
+		if err := writers.WriteToCassandra(ctx, systems, cfg.ConfigFile); err != nil {
 			log.Fatalf("Failed to write to Cassandra: %v", err)
 		}
 	default:
 		log.Fatalf("Unsupported output format: %s", cfg.OutputFormat)
 	}
 
-	duration = time.Since(startTime)
-	fmt.Printf("Output written successfully in %v\n", duration)
+	fmt.Printf("Generated %d stars, %d planets, %d moons, %d exoplanets (%d detected)\n",
+		stats.stars, stats.planets, stats.moons, stats.exoplanets, stats.detected)
+	if stats.dropped > 0 {
+		fmt.Printf("Dropped %d planets and exoplanets that could not be placed on stable orbits\n", stats.dropped)
+	}
+	fmt.Printf("Output written successfully in %v\n", time.Since(startTime))
 	fmt.Println("\nDone!")
 }
+
+// generationStats counts the entities passed to an output writer
+type generationStats struct {
+	stars      int
+	planets    int
+	moons      int
+	exoplanets int
+	detected   int
+	dropped    int
+}
+
+// add tallies the entities of one system
+func (s *generationStats) add(system generator.System) {
+	s.stars += 1 + len(system.Companions)
+	s.planets += len(system.Planets)
+	s.moons += len(system.Moons)
+	s.exoplanets += len(system.Exoplanets)
+	s.dropped += system.Dropped
+	for _, exo := range system.Exoplanets {
+		if exo.Detected {
+			s.detected++
+		}
+	}
+}
+
+// countSystems forwards systems from in while tallying them into stats.
+// The counts are complete once the returned channel has been drained.
+// Consumers that stop reading early must cancel ctx so the forwarding
+// goroutine can exit.
+func countSystems(ctx context.Context, in <-chan generator.System, stats *generationStats) <-chan generator.System {
+	out := make(chan generator.System)
+
+	go func() {
+		defer close(out)
+		for system := range in {
+			stats.add(system)
+			select {
+			case out <- system:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// generatorConfig returns the generator configuration of the dataset with
+// the given seed and generation flags, including its population model
+func generatorConfig(flags config.GenerationFlags, seed int64) (generator.Config, error) {
+	genCfg := generator.Config{
+		PlanetsPerStar: flags.PlanetsPerStar,
+		ExoPerStar:     flags.ExoPerStar,
+		Seed:           seed,
+		Compositions:   flags.Compositions,
+		Designations:   flags.Designations,
+	}
+	if flags.LightCurves {
+		genCfg.LightCurves = &generator.LightCurveConfig{
+			Cadence:  flags.LightCurveCadence,
+			Duration: time.Duration(flags.LightCurveDays * float64(24*time.Hour)),
+			Noise:    flags.LightCurveNoise,
+		}
+	}
+	if flags.RVObservations > 0 {
+		genCfg.RadialVelocities = &generator.RVConfig{
+			Observations: flags.RVObservations,
+			Baseline:     time.Duration(flags.RVDays * float64(24*time.Hour)),
+			Error:        flags.RVError,
+			Jitter:       flags.RVJitter,
+		}
+	}
+	err := applyPopulation(&genCfg, flags.PopulationFile)
+	return genCfg, err
+}
+
+// applyPopulation loads the population model file, if any, into genCfg
+func applyPopulation(genCfg *generator.Config, filename string) error {
+	if filename == "" {
+		return nil
+	}
+
+	population, err := config.LoadPopulationConfig(filename)
+	if err != nil {
+		return err
+	}
+
+	genCfg.LuminosityFractions = population.LuminosityFractions
+	genCfg.StarModel = population.StarParameters.Model
+	genCfg.StarScatter = population.StarParameters.Scatter
+
+	genCfg.SpatialModel = population.Spatial.Model
+	genCfg.MaxDistance = population.Spatial.MaxDistance
+	genCfg.OrbitSpacing = population.Orbits.Spacing
+	genCfg.Greenhouse = population.Climate.Greenhouse
+
+	if o := population.Occurrence; o != nil {
+		genCfg.Occurrence = &generator.OccurrenceConfig{}
+		if o.Rates != nil {
+			genCfg.Occurrence.Rates = make(map[string]generator.PlanetKinds, len(o.Rates))
+			for class, rate := range o.Rates {
+				genCfg.Occurrence.Rates[class] = generator.PlanetKinds(rate)
+			}
+		}
+		if e := o.MetallicityExponents; e != nil {
+			exponents := generator.PlanetKinds(*e)
+			genCfg.Occurrence.MetallicityExponents = &exponents
+		}
+	}
+
+	if m := population.Multiplicity; m != nil {
+		genCfg.Multiplicity = &generator.MultiplicityConfig{
+			Fractions:      m.Fractions,
+			TripleFraction: m.TripleFraction,
+		}
+	}
+
+	if a := population.Architectures; a != nil {
+		genCfg.Architectures = generator.DefaultArchitectures
+		if a.Templates != nil {
+			genCfg.Architectures = make([]generator.Architecture, len(a.Templates))
+			for i, t := range a.Templates {
+				arch, builtin := generator.BuiltinArchitecture(t.Name)
+				if !builtin && len(t.Planets) == 0 {
+					return fmt.Errorf("architecture template '%s' has no planets and is not built in", t.Name)
+				}
+				arch.Name, arch.Weight = t.Name, t.Weight
+				if len(t.Planets) > 0 {
+					arch.Planets = make([]generator.PlanetGroup, len(t.Planets))
+					for j, g := range t.Planets {
+						arch.Planets[j] = generator.PlanetGroup(g)
+					}
+				}
+				genCfg.Architectures[i] = arch
+			}
+		}
+	}
+
+	if s := population.Surveys; s != nil {
+		genCfg.Surveys = &generator.SurveyConfig{
+			TransitNoise:      s.Transit.Noise,
+			TransitBaseline:   time.Duration(s.Transit.BaselineDays * float64(24*time.Hour)),
+			MinTransitSNR:     s.Transit.MinSNR,
+			RVPrecision:       s.RadialVelocity.Precision,
+			RVObservations:    s.RadialVelocity.Observations,
+			MinRVSNR:          s.RadialVelocity.MinSNR,
+			ImagingContrast:   s.Imaging.Contrast,
+			ImagingInnerAngle: s.Imaging.InnerAngle,
+		}
+	}
+
+	if imf := population.IMF; imf != nil {
+		genCfg.IMF = &generator.IMF{
+			Model:              imf.Model,
+			MinMass:            imf.MinMass,
+			MaxMass:            imf.MaxMass,
+			Slopes:             imf.Slopes,
+			CharacteristicMass: imf.CharacteristicMass,
+			Sigma:              imf.Sigma,
+		}
+	}
+	return nil
+}
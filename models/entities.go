@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // Star represents a stellar object with physical characteristics
 type Star struct {
 	ID           string  // UUID string
@@ -8,6 +10,57 @@ type Star struct {
 	Mass         float64 // Mass in solar masses
 	Radius       float64 // Radius in solar radii
 	Temperature  int32   // Surface temperature in Kelvin
+
+	Age            float64 // Age in Gyr
+	Metallicity    float64 // Iron abundance [Fe/H] in dex
+	Luminosity     float64 // Luminosity in solar luminosities
+	SurfaceGravity float64 // Surface gravity log g (cgs)
+
+	RA             float64 // Right ascension (ICRS) in degrees
+	Dec            float64 // Declination (ICRS) in degrees
+	Distance       float64 // Distance from the Sun in parsecs
+	Parallax       float64 // Parallax in milliarcseconds
+	PMRA           float64 // Proper motion in right ascension (mu_alpha*) in mas/yr
+	PMDec          float64 // Proper motion in declination in mas/yr
+	RadialVelocity float64 // Radial velocity in km/s
+
+	HZInner           float64 // Inner edge of the conservative habitable zone (runaway greenhouse) in AU
+	HZOuter           float64 // Outer edge of the conservative habitable zone (maximum greenhouse) in AU
+	HZOptimisticInner float64 // Inner edge of the optimistic habitable zone (recent Venus) in AU
+	HZOptimisticOuter float64 // Outer edge of the optimistic habitable zone (early Mars) in AU
+
+	SystemID  string // Foreign key to StarSystem (empty for single stars)
+	Component string // Component within the system: "A", "B" or "C" (empty for single stars)
+
+	Architecture string // Planetary system template, e.g. "hot-jupiter" (empty without templates)
+}
+
+// StarSystem links the components of a binary or triple star system. The
+// secondary orbits the primary, and a tertiary orbits the inner pair.
+type StarSystem struct {
+	ID           string // UUID string
+	Name         string // System name (the primary's name)
+	Multiplicity int32  // Number of stars (2 or 3)
+
+	PrimaryID    string  // Foreign key to the primary Star (component A)
+	SecondaryID  string  // Foreign key to the secondary Star (component B)
+	Separation   float64 // Semi-major axis of the A-B orbit in AU
+	Period       float64 // Period of the A-B orbit in days
+	Eccentricity float64 // Eccentricity of the A-B orbit
+	MassRatio    float64 // Secondary mass over primary mass
+
+	TertiaryID        string  // Foreign key to the tertiary Star (component C, empty for binaries)
+	OuterSeparation   float64 // Semi-major axis of the AB-C orbit in AU
+	OuterPeriod       float64 // Period of the AB-C orbit in days
+	OuterEccentricity float64 // Eccentricity of the AB-C orbit
+	OuterMassRatio    float64 // Tertiary mass over the mass of the inner pair
+}
+
+// StarIdentifier is one catalog designation of a star
+type StarIdentifier struct {
+	StarID     string // Foreign key to Star
+	Catalog    string // Catalog name, e.g. "HD", "2MASS" or "Gaia DR3"
+	Identifier string // Designation within the catalog, e.g. "HD 209458"
 }
 
 // Planet represents a planet orbiting a star
@@ -17,28 +70,105 @@ type Planet struct {
 	OrbitalPeriod float64 // Orbital period in Earth days
 	SemiMajorAxis float64 // Semi-major axis in AU
 	Eccentricity  float64 // Orbital eccentricity (0-1)
+
+	Inclination              float64 // Inclination to the sky plane in degrees (0-180)
+	LongitudeOfAscendingNode float64 // Longitude of the ascending node in degrees (0-360)
+	ArgumentOfPeriapsis      float64 // Argument of periapsis in degrees (0-360)
+	MeanAnomaly              float64 // Mean anomaly at Epoch in degrees (0-360)
+	Epoch                    float64 // Reference epoch of MeanAnomaly as a Julian Date
+
+	Insolation      float64 // Orbit-averaged stellar flux relative to Earth
+	BondAlbedo      float64 // Bond albedo (0-1)
+	EquilibriumTemp float64 // Equilibrium temperature in Kelvin
+	InHabitableZone bool    // Whether Insolation is within the star's conservative habitable zone
+
+	Mass          float64            // Mass in Earth masses
+	Radius        float64            // Radius in Earth radii
+	PlanetClass   string             // terrestrial, super-Earth, sub-Neptune, Neptune or Jovian
+	Atmosphere    string             // Atmospheric composition description
+	Composition   map[string]float64 // Mole fraction of each molecule (with Config.Compositions)
+	SurfaceTemp   int32              // Surface temperature in Kelvin (equilibrium, plus greenhouse warming if enabled)
+	HasRings      bool               // Whether the planet has rings
+	HasMoons      bool               // Whether the planet has at least one Moon
+	DiscoveryYear int32              // Year of discovery
+	OrbitType     string             // "S-type" (one star) or "P-type" (circumbinary) in multiple systems
+	StarID        string             // Foreign key to parent Star (the primary for P-type orbits)
+}
+
+// Moon represents a natural satellite of a planet
+type Moon struct {
+	ID            string  // UUID string
+	Name          string  // Moon name
+	OrbitalRadius float64 // Semi-major axis around the planet in km, within its Hill sphere
+	OrbitalPeriod float64 // Orbital period in Earth days
 	Mass          float64 // Mass in Earth masses
 	Radius        float64 // Radius in Earth radii
-	Atmosphere    string  // Atmospheric composition description
-	SurfaceTemp   int32   // Surface temperature in Kelvin
-	HasRings      bool    // Whether the planet has rings
-	HasMoons      bool    // Whether the planet has moons
-	DiscoveryYear int32   // Year of discovery
-	StarID        string  // Foreign key to parent Star
+	TidallyLocked bool    // Whether the moon always shows the same face to its planet
+	PlanetID      string  // Foreign key to parent Planet
 }
 
 // Exoplanet represents an exoplanet orbiting a distant star
 type Exoplanet struct {
-	ID              string  // UUID string
-	Name            string  // Exoplanet name
-	OrbitalPeriod   float64 // Orbital period in Earth days
-	SemiMajorAxis   float64 // Semi-major axis in AU
-	Eccentricity    float64 // Orbital eccentricity (0-1)
+	ID            string  // UUID string
+	Name          string  // Exoplanet name
+	OrbitalPeriod float64 // Orbital period in Earth days
+	SemiMajorAxis float64 // Semi-major axis in AU
+	Eccentricity  float64 // Orbital eccentricity (0-1)
+
+	Inclination              float64 // Inclination to the sky plane in degrees (0-180)
+	LongitudeOfAscendingNode float64 // Longitude of the ascending node in degrees (0-360)
+	ArgumentOfPeriapsis      float64 // Argument of periapsis in degrees (0-360)
+	MeanAnomaly              float64 // Mean anomaly at Epoch in degrees (0-360)
+	Epoch                    float64 // Reference epoch of MeanAnomaly as a Julian Date
+
+	Insolation      float64 // Orbit-averaged stellar flux relative to Earth
+	BondAlbedo      float64 // Bond albedo (0-1)
+	EquilibriumTemp float64 // Equilibrium temperature in Kelvin
+	InHabitableZone bool    // Whether Insolation is within the star's conservative habitable zone
+
 	Mass            float64 // Mass in Earth masses
 	Radius          float64 // Radius in Earth radii
-	DetectionMethod string  // Method used to detect the exoplanet
+	PlanetClass     string  // terrestrial, super-Earth, sub-Neptune, Neptune or Jovian
+	DetectionMethod string  // Method used to detect the exoplanet (empty if not detected)
 	HostDistance    float64 // Distance to host star in light years
-	SurfaceTemp     int32   // Surface temperature in Kelvin
-	DiscoveryYear   int32   // Year of discovery
-	StarID          string  // Foreign key to parent Star
+	SurfaceTemp     int32   // Surface temperature in Kelvin (equilibrium temperature)
+	DiscoveryYear   int32   // Year of discovery (0 if not detected)
+	Detected        bool    // Whether the exoplanet is in the observed catalog
+	OrbitType       string  // "S-type" (one star) or "P-type" (circumbinary) in multiple systems
+	StarID          string  // Foreign key to parent Star (the primary for P-type orbits)
+}
+
+// LightCurvePoint is one photometric measurement of a star hosting a
+// transiting exoplanet
+type LightCurvePoint struct {
+	ExoplanetID string  // Foreign key to the transiting Exoplanet
+	Time        float64 // Observation time as a Julian Date
+	Flux        float64 // Stellar flux normalised to 1 out of transit, with noise
+	FluxError   float64 // 1 sigma flux uncertainty
+	InTransit   bool    // Whether the planet is in front of the star (label)
+}
+
+// RVObservation is one radial-velocity measurement of a host star, listed
+// once for each exoplanet detected by radial velocity around it
+type RVObservation struct {
+	ExoplanetID    string  // Foreign key to the Exoplanet
+	StarID         string  // Foreign key to the host Star
+	Time           float64 // Observation time as a Julian Date
+	RadialVelocity float64 // Measured velocity of the star away from the observer in m/s (all planets plus noise)
+	Error          float64 // 1 sigma instrumental error in m/s
+	Signal         float64 // Noiseless contribution of this exoplanet in m/s
+}
+
+// EphemerisPoint is the position and velocity of a planet or exoplanet
+// relative to its star at one instant. The reference plane is the sky plane,
+// with Z along the line of sight towards the observer.
+type EphemerisPoint struct {
+	PlanetID  string    // Foreign key to Planet or Exoplanet
+	Timestamp time.Time // Time of the position (UTC)
+	X         float64   // Position in AU
+	Y         float64   // Position in AU
+	Z         float64   // Position in AU
+	VX        float64   // Velocity in AU/day
+	VY        float64   // Velocity in AU/day
+	VZ        float64   // Velocity in AU/day
 }
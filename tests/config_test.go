@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"djdees/synthetic_stellar_data/config"
+)
+
+// writePopulation writes a population file with the given contents
+func writePopulation(t *testing.T, contents string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "population.yml")
+	if err := os.WriteFile(filename, []byte(contents), 0644); err != nil {
+		t.Fatalf("Failed to write population file: %v", err)
+	}
+	return filename
+}
+
+func TestPopulationArchitectures(t *testing.T) {
+	// Built-in templates may leave out their planets
+	population, err := config.LoadPopulationConfig(writePopulation(t, `
+architectures:
+  templates:
+    - {name: hot-jupiter, weight: 0.5}
+    - {name: debris-disk, weight: 0.5}
+`))
+	if err != nil {
+		t.Fatalf("Failed to load built-in templates: %v", err)
+	}
+	if len(population.Architectures.Templates) != 2 {
+		t.Errorf("Loaded %d templates, expected 2", len(population.Architectures.Templates))
+	}
+
+	// A mistyped name without planets is not an empty template
+	_, err = config.LoadPopulationConfig(writePopulation(t, `
+architectures:
+  templates:
+    - {name: hot-jupter, weight: 1}
+`))
+	if err == nil || !strings.Contains(err.Error(), "hot-jupter") {
+		t.Errorf("Expected an error naming the unknown template, got %v", err)
+	}
+}
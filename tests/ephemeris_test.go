@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"djdees/synthetic_stellar_data/generator"
+	"djdees/synthetic_stellar_data/models"
+)
+
+var j2000 = time.Date(2000, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+func TestPlanetPositionCircularOrbit(t *testing.T) {
+	planet := models.Planet{
+		ID:            "p1",
+		OrbitalPeriod: 365.25,
+		SemiMajorAxis: 1.0,
+		Epoch:         2451545.0,
+	}
+
+	// Face-on circular orbit starting at periapsis on the x axis
+	point := generator.PlanetPosition(planet, j2000)
+	speed := 2 * math.Pi / 365.25
+	if math.Abs(point.X-1) > 1e-9 || math.Abs(point.Y) > 1e-9 || math.Abs(point.Z) > 1e-9 {
+		t.Errorf("Position at epoch (%f, %f, %f), expected (1, 0, 0)", point.X, point.Y, point.Z)
+	}
+	if math.Abs(point.VX) > 1e-9 || math.Abs(point.VY-speed) > 1e-9 || math.Abs(point.VZ) > 1e-9 {
+		t.Errorf("Velocity at epoch (%f, %f, %f), expected (0, %f, 0)", point.VX, point.VY, point.VZ, speed)
+	}
+	if point.PlanetID != "p1" {
+		t.Errorf("PlanetID %q, expected p1", point.PlanetID)
+	}
+
+	// A quarter period later the planet is on the y axis
+	point = generator.PlanetPosition(planet, j2000.Add(time.Duration(365.25/4*24*float64(time.Hour))))
+	if math.Abs(point.X) > 1e-6 || math.Abs(point.Y-1) > 1e-6 {
+		t.Errorf("Position after a quarter period (%f, %f), expected (0, 1)", point.X, point.Y)
+	}
+}
+
+func TestPlanetPositionConservesEnergy(t *testing.T) {
+	cfg := generator.Config{NumStars: 50, PlanetsPerStar: 5, Seed: 1313}
+
+	for _, planet := range generator.GenerateAll(cfg).Planets {
+		n := 2 * math.Pi / planet.OrbitalPeriod
+		mu := n * n * math.Pow(planet.SemiMajorAxis, 3) // GM in AU^3/day^2
+
+		for day := 0; day < 1000; day += 97 {
+			p := generator.PlanetPosition(planet, j2000.AddDate(0, 0, day))
+			r := math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z)
+			v2 := p.VX*p.VX + p.VY*p.VY + p.VZ*p.VZ
+
+			// Distance stays between periapsis and apoapsis
+			a, e := planet.SemiMajorAxis, planet.Eccentricity
+			if r < a*(1-e)*(1-1e-9) || r > a*(1+e)*(1+1e-9) {
+				t.Fatalf("%s at %f AU, outside [%f, %f]", planet.Name, r, a*(1-e), a*(1+e))
+			}
+
+			// Vis-viva equation
+			if want := mu * (2/r - 1/a); math.Abs(v2-want) > 1e-9*want {
+				t.Fatalf("%s has v^2 %g, vis-viva gives %g", planet.Name, v2, want)
+			}
+		}
+	}
+}
+
+func TestEphemerisStream(t *testing.T) {
+	cfg := generator.Config{NumStars: 10, PlanetsPerStar: 3, ExoPerStar: 2, Seed: 1314}
+	rng := generator.EphemerisRange{
+		Start:   time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		End:     time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC),
+		Cadence: time.Hour,
+	}
+
+	data := generator.GenerateAll(cfg)
+	bodies := len(data.Planets) + len(data.Exoplanets)
+
+	count := 0
+	perBody := make(map[string]int)
+	for point := range generator.Ephemeris(context.Background(), cfg, rng) {
+		count++
+		perBody[point.PlanetID]++
+	}
+
+	if want := bodies * 25; count != want {
+		t.Errorf("Got %d ephemeris points, expected %d", count, want)
+	}
+	if len(perBody) != bodies {
+		t.Errorf("Got points for %d bodies, expected %d", len(perBody), bodies)
+	}
+}
@@ -1,9 +1,17 @@
 package tests
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
 	"testing"
 
 	"djdees/synthetic_stellar_data/generator"
+	"djdees/synthetic_stellar_data/models"
 )
 
 func TestGenerateAllBasic(t *testing.T) {
@@ -62,6 +70,163 @@ func TestGenerateAllReproducibility(t *testing.T) {
 	}
 }
 
+func TestGenerateAllDeterministicIDs(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:       25,
+		PlanetsPerStar: 4,
+		ExoPerStar:     3,
+		Seed:           4242,
+	}
+
+	data1 := generator.GenerateAll(cfg)
+	data2 := generator.GenerateAll(cfg)
+
+	if !reflect.DeepEqual(data1, data2) {
+		t.Fatal("Generated data differs between runs with the same seed")
+	}
+
+	// IDs must still be unique across all entity types
+	seen := make(map[string]bool)
+	check := func(id string) {
+		if seen[id] {
+			t.Errorf("Duplicate ID generated: %s", id)
+		}
+		seen[id] = true
+	}
+	for _, star := range data1.Stars {
+		check(star.ID)
+	}
+	for _, planet := range data1.Planets {
+		check(planet.ID)
+	}
+	for _, exo := range data1.Exoplanets {
+		check(exo.ID)
+	}
+
+	// A different seed must produce different IDs
+	cfg.Seed = 4243
+	data3 := generator.GenerateAll(cfg)
+	if data3.Stars[0].ID == data1.Stars[0].ID {
+		t.Error("Different seeds produced the same star ID")
+	}
+}
+
+func TestStreamMatchesGenerateAll(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:       30,
+		PlanetsPerStar: 5,
+		ExoPerStar:     3,
+		Seed:           2024,
+	}
+
+	data := generator.GenerateAll(cfg)
+
+	streamed := &generator.GeneratedData{
+		Stars:      make([]models.Star, 0),
+		Planets:    make([]models.Planet, 0),
+		Moons:      make([]models.Moon, 0),
+		Exoplanets: make([]models.Exoplanet, 0),
+	}
+	for system := range generator.Stream(context.Background(), cfg) {
+		streamed.Stars = append(streamed.Stars, system.Star)
+		streamed.Planets = append(streamed.Planets, system.Planets...)
+		streamed.Moons = append(streamed.Moons, system.Moons...)
+		streamed.Exoplanets = append(streamed.Exoplanets, system.Exoplanets...)
+
+		for _, planet := range system.Planets {
+			if planet.StarID != system.Star.ID {
+				t.Errorf("Planet %s streamed with the wrong star", planet.Name)
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(data, streamed) {
+		t.Error("Streamed systems differ from GenerateAll output")
+	}
+}
+
+func TestParallelGenerationMatchesSequential(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:       200,
+		PlanetsPerStar: 5,
+		ExoPerStar:     3,
+		Seed:           8675309,
+	}
+
+	sequential := generator.GenerateAll(cfg)
+
+	for _, workers := range []int{2, 3, 8} {
+		cfg.Workers = workers
+		parallel := generator.GenerateAll(cfg)
+		if !reflect.DeepEqual(sequential, parallel) {
+			t.Errorf("Output with %d workers differs from sequential generation", workers)
+		}
+	}
+}
+
+func TestGenerateSystemRandomAccess(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:       50,
+		PlanetsPerStar: 6,
+		ExoPerStar:     4,
+		Seed:           31337,
+	}
+
+	index := 0
+	for system := range generator.Stream(context.Background(), cfg) {
+		regenerated := generator.GenerateSystem(cfg, index)
+		if !reflect.DeepEqual(system, regenerated) {
+			t.Fatalf("GenerateSystem(%d) differs from the streamed system", index)
+		}
+		index++
+	}
+}
+
+func TestShardsConcatenateToFullDataset(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:       103,
+		PlanetsPerStar: 4,
+		ExoPerStar:     3,
+		Seed:           5150,
+	}
+
+	full := generator.GenerateAll(cfg)
+
+	for _, shardCount := range []int{2, 4, 7} {
+		combined := &generator.GeneratedData{
+			Stars:      make([]models.Star, 0),
+			Planets:    make([]models.Planet, 0),
+			Moons:      make([]models.Moon, 0),
+			Exoplanets: make([]models.Exoplanet, 0),
+		}
+
+		for k := 0; k < shardCount; k++ {
+			shardCfg := cfg
+			shardCfg.ShardIndex = k
+			shardCfg.ShardCount = shardCount
+			shardCfg.Workers = k % 3 // mix sequential and parallel shards
+
+			shard := generator.GenerateAll(shardCfg)
+			combined.Stars = append(combined.Stars, shard.Stars...)
+			combined.Planets = append(combined.Planets, shard.Planets...)
+			combined.Moons = append(combined.Moons, shard.Moons...)
+			combined.Exoplanets = append(combined.Exoplanets, shard.Exoplanets...)
+		}
+
+		if !reflect.DeepEqual(full, combined) {
+			t.Errorf("Concatenated %d shards differ from a single run", shardCount)
+		}
+
+		names := make(map[string]bool)
+		for _, star := range combined.Stars {
+			if names[star.Name] {
+				t.Errorf("Duplicate star name across shards: %s", star.Name)
+			}
+			names[star.Name] = true
+		}
+	}
+}
+
 func TestStarValidation(t *testing.T) {
 	cfg := generator.Config{
 		NumStars:       100,
@@ -95,6 +260,269 @@ func TestStarValidation(t *testing.T) {
 		if len(star.SpectralType) < 2 {
 			t.Errorf("Star %s has invalid spectral type: %s", star.Name, star.SpectralType)
 		}
+
+		// Check derived quantities
+		if star.Age <= 0 || star.Age > 13.0 {
+			t.Errorf("Star %s has invalid age: %f Gyr", star.Name, star.Age)
+		}
+
+		if star.Metallicity < -2.5 || star.Metallicity > 0.5 {
+			t.Errorf("Star %s has invalid metallicity: %f", star.Name, star.Metallicity)
+		}
+
+		tempRatio := float64(star.Temperature) / 5772.0
+		luminosity := star.Radius * star.Radius * math.Pow(tempRatio, 4)
+		if math.Abs(star.Luminosity-luminosity) > 1e-9*luminosity {
+			t.Errorf("Star %s luminosity %g does not match R²T⁴ (%g)", star.Name, star.Luminosity, luminosity)
+		}
+
+		logG := 4.438 + math.Log10(star.Mass) - 2*math.Log10(star.Radius)
+		if math.Abs(star.SurfaceGravity-logG) > 1e-9 {
+			t.Errorf("Star %s surface gravity %f, expected %f", star.Name, star.SurfaceGravity, logG)
+		}
+	}
+}
+
+func TestAgeMetallicityCorrelation(t *testing.T) {
+	cfg := generator.Config{NumStars: 2000, Seed: 909}
+
+	// Older stars should be more metal-poor on average
+	var young, old []float64
+	for _, star := range generator.GenerateAll(cfg).Stars {
+		checkMainSequenceAge(t, star)
+		if star.Age < 3 {
+			young = append(young, star.Metallicity)
+		} else if star.Age > 9 {
+			old = append(old, star.Metallicity)
+		}
+	}
+	if len(young) == 0 || len(old) == 0 {
+		t.Fatalf("Expected both young and old stars, got %d young and %d old", len(young), len(old))
+	}
+
+	if mean(young) <= mean(old) {
+		t.Errorf("Mean [Fe/H] of young stars (%f) is not above old stars (%f)", mean(young), mean(old))
+	}
+
+	// Massive main-sequence stars are younger than their short lifetimes
+	cfg.IMF = &generator.IMF{Model: generator.IMFSalpeter, MinMass: 10, MaxMass: 60}
+	cfg.LuminosityFractions = map[string]float64{generator.LuminosityMainSequence: 1}
+	for _, star := range generator.GenerateAll(cfg).Stars {
+		checkMainSequenceAge(t, star)
+	}
+}
+
+// checkMainSequenceAge checks that a main-sequence star is no older than
+// its main-sequence lifetime of 10 M^-2.5 Gyr
+func checkMainSequenceAge(t *testing.T, star models.Star) {
+	t.Helper()
+	if !strings.HasSuffix(star.SpectralType, "V") || strings.HasSuffix(star.SpectralType, "IV") {
+		return
+	}
+	if lifetime := 10 * math.Pow(star.Mass, -2.5); star.Age > lifetime {
+		t.Errorf("Main-sequence star %s (%s, %.1f solar masses) is %.4f Gyr old, past its lifetime of %.4f Gyr",
+			star.Name, star.SpectralType, star.Mass, star.Age, lifetime)
+	}
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func TestLuminosityClassFractions(t *testing.T) {
+	cases := []struct {
+		class string
+		check func(spectralType string) bool
+	}{
+		{generator.LuminosityGiant, func(st string) bool { return strings.HasSuffix(st, "III") }},
+		{generator.LuminositySupergiant, func(st string) bool {
+			return strings.HasSuffix(st, "I") && !strings.HasSuffix(st, "II")
+		}},
+		{generator.LuminosityWhiteDwarf, func(st string) bool { return strings.HasPrefix(st, "D") }},
+		{generator.LuminosityBrownDwarfT, func(st string) bool { return strings.HasPrefix(st, "T") }},
+	}
+
+	for _, tc := range cases {
+		cfg := generator.Config{
+			NumStars:            50,
+			PlanetsPerStar:      2,
+			ExoPerStar:          2,
+			Seed:                606,
+			LuminosityFractions: map[string]float64{tc.class: 1},
+		}
+
+		data := generator.GenerateAll(cfg)
+		for _, star := range data.Stars {
+			if !tc.check(star.SpectralType) {
+				t.Errorf("Star %s has spectral type %s, expected luminosity class %s",
+					star.Name, star.SpectralType, tc.class)
+			}
+		}
+	}
+
+	// The default mix must include evolved stars
+	cfg := generator.Config{NumStars: 2000, Seed: 607}
+	giants := 0
+	for _, star := range generator.GenerateAll(cfg).Stars {
+		if strings.HasSuffix(star.SpectralType, "III") {
+			giants++
+		}
+	}
+	if giants == 0 {
+		t.Error("Default population contains no giants")
+	}
+}
+
+func TestMassModelSubclassMatchesTemperature(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:            2000,
+		Seed:                707,
+		LuminosityFractions: map[string]float64{generator.LuminosityMainSequence: 1},
+		StarModel:           generator.StarModelMass,
+		StarScatter:         0.05,
+	}
+
+	// Temperature range seen for each class letter and subclass digit
+	type tempRange struct{ min, max int32 }
+	ranges := make(map[byte]map[byte]*tempRange)
+	for _, star := range generator.GenerateAll(cfg).Stars {
+		class, subclass := star.SpectralType[0], star.SpectralType[1]
+		if ranges[class] == nil {
+			ranges[class] = make(map[byte]*tempRange)
+		}
+		tr := ranges[class][subclass]
+		if tr == nil {
+			ranges[class][subclass] = &tempRange{star.Temperature, star.Temperature}
+			continue
+		}
+		if star.Temperature < tr.min {
+			tr.min = star.Temperature
+		}
+		if star.Temperature > tr.max {
+			tr.max = star.Temperature
+		}
+	}
+
+	// Within a class, every star of a lower subclass must be hotter
+	for class, subclasses := range ranges {
+		for a, ra := range subclasses {
+			for b, rb := range subclasses {
+				if a < b && ra.min < rb.max {
+					t.Errorf("%c%cV (min %d K) is not hotter than %c%cV (max %d K)",
+						class, a, ra.min, class, b, rb.max)
+				}
+			}
+		}
+	}
+}
+
+func TestIMFSampling(t *testing.T) {
+	mainSequence := map[string]float64{generator.LuminosityMainSequence: 1}
+
+	// Salpeter between 1 and 100 solar masses has a median of about 1.67
+	cfg := generator.Config{
+		NumStars:            5000,
+		Seed:                808,
+		LuminosityFractions: mainSequence,
+		IMF:                 &generator.IMF{Model: generator.IMFSalpeter, MinMass: 1, MaxMass: 100},
+	}
+	stars := generator.GenerateAll(cfg).Stars
+	masses := make([]float64, len(stars))
+	for i, star := range stars {
+		if star.Mass < 1 || star.Mass > 100 {
+			t.Errorf("Star %s mass %f outside IMF bounds", star.Name, star.Mass)
+		}
+		masses[i] = star.Mass
+	}
+	sort.Float64s(masses)
+	if median := masses[len(masses)/2]; median < 1.5 || median > 1.85 {
+		t.Errorf("Salpeter median mass %f, expected about 1.67", median)
+	}
+
+	// Classes follow from mass, and a low-mass Kroupa IMF yields brown dwarfs
+	for _, model := range []string{generator.IMFKroupa, generator.IMFChabrier} {
+		cfg.IMF = &generator.IMF{Model: model, MinMass: 0.01, MaxMass: 100}
+		brownDwarfs := 0
+		for _, star := range generator.GenerateAll(cfg).Stars {
+			switch star.SpectralType[0] {
+			case 'L', 'T', 'Y':
+				brownDwarfs++
+				if star.Mass >= 0.08 {
+					t.Errorf("%s: brown dwarf %s has mass %f", model, star.SpectralType, star.Mass)
+				}
+			case 'G':
+				if star.Mass < 0.8 || star.Mass >= 1.04 {
+					t.Errorf("%s: %s star has mass %f", model, star.SpectralType, star.Mass)
+				}
+			}
+		}
+		if brownDwarfs == 0 {
+			t.Errorf("%s: no brown dwarfs sampled below 0.08 solar masses", model)
+		}
+	}
+}
+
+func TestSpatialModels(t *testing.T) {
+	// Height above the Galactic plane, from the ICRS unit vector dotted with
+	// the north Galactic pole
+	height := func(star models.Star) float64 {
+		ra, dec := star.RA*math.Pi/180, star.Dec*math.Pi/180
+		sinB := -0.8676661490190047*math.Cos(dec)*math.Cos(ra) -
+			0.1980763734312015*math.Cos(dec)*math.Sin(ra) +
+			0.4559837761750669*math.Sin(dec)
+		return math.Abs(star.Distance * sinB)
+	}
+
+	meanHeight := make(map[string]float64)
+	for _, model := range []string{generator.SpatialUniform, generator.SpatialThinDisk, generator.SpatialThickDisk} {
+		cfg := generator.Config{
+			NumStars:     1000,
+			ExoPerStar:   3,
+			Seed:         1010,
+			SpatialModel: model,
+			MaxDistance:  2000,
+		}
+		data := generator.GenerateAll(cfg)
+
+		distances := make(map[string]float64)
+		var heights []float64
+		for _, star := range data.Stars {
+			distances[star.ID] = star.Distance
+			heights = append(heights, height(star))
+
+			if star.Distance < 1 || star.Distance > 2000 {
+				t.Errorf("%s: star %s at %f pc outside the generated volume", model, star.Name, star.Distance)
+			}
+			if star.RA < 0 || star.RA >= 360 || star.Dec < -90 || star.Dec > 90 {
+				t.Errorf("%s: star %s has invalid position (%f, %f)", model, star.Name, star.RA, star.Dec)
+			}
+			if math.Abs(star.Parallax*star.Distance-1000) > 1e-6 {
+				t.Errorf("%s: star %s parallax %f does not match distance %f", model, star.Name, star.Parallax, star.Distance)
+			}
+
+			tangential := 4.74047 * math.Hypot(star.PMRA, star.PMDec) * star.Distance / 1000
+			if speed := math.Hypot(tangential, star.RadialVelocity); speed > 600 {
+				t.Errorf("%s: star %s moves at %f km/s", model, star.Name, speed)
+			}
+		}
+		meanHeight[model] = mean(heights)
+
+		// Exoplanets share the distance of their host star
+		for _, exo := range data.Exoplanets {
+			if want := distances[exo.StarID] * 3.26156; math.Abs(exo.HostDistance-want) > 1e-9*want {
+				t.Errorf("%s: exoplanet %s host distance %f ly, expected %f", model, exo.Name, exo.HostDistance, want)
+			}
+		}
+	}
+
+	// Disk populations concentrate towards the Galactic plane
+	if meanHeight[generator.SpatialThinDisk] >= meanHeight[generator.SpatialThickDisk] ||
+		meanHeight[generator.SpatialThickDisk] >= meanHeight[generator.SpatialUniform] {
+		t.Errorf("Mean height above the plane not ordered thin < thick < uniform: %v", meanHeight)
 	}
 }
 
@@ -149,6 +577,598 @@ func TestPlanetValidation(t *testing.T) {
 	}
 }
 
+func TestOccurrenceRates(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:       4000,
+		PlanetsPerStar: 15,
+		ExoPerStar:     8,
+		Seed:           2323,
+		Occurrence:     &generator.OccurrenceConfig{},
+	}
+
+	type tally struct{ stars, rocky, giantHosts int }
+	byClass := make(map[string]*tally)
+	var metalRich, metalPoor tally
+	for system := range generator.Stream(context.Background(), cfg) {
+		star := system.Star
+		if len(system.Planets) > cfg.PlanetsPerStar || len(system.Exoplanets) > cfg.ExoPerStar {
+			t.Errorf("%s has %d planets and %d exoplanets", star.Name, len(system.Planets), len(system.Exoplanets))
+		}
+
+		class := star.SpectralType[:1]
+		if byClass[class] == nil {
+			byClass[class] = &tally{}
+		}
+		counts := []*tally{byClass[class]}
+		switch {
+		case star.Metallicity > 0.1:
+			counts = append(counts, &metalRich)
+		case star.Metallicity < -0.3:
+			counts = append(counts, &metalPoor)
+		}
+
+		giant := false
+		for _, p := range system.Planets {
+			if p.Mass > 20 {
+				giant = true
+			}
+		}
+		for _, c := range counts {
+			c.stars++
+			if giant {
+				c.giantHosts++
+			}
+			for _, p := range system.Planets {
+				if p.Mass <= 5 {
+					c.rocky++
+				}
+			}
+		}
+	}
+
+	// M dwarfs host more small planets than G stars
+	m, g := byClass["M"], byClass["G"]
+	if m == nil || g == nil || m.stars == 0 || g.stars == 0 {
+		t.Fatal("Expected M and G stars")
+	}
+	if float64(m.rocky)/float64(m.stars) <= 1.5*float64(g.rocky)/float64(g.stars) {
+		t.Errorf("M dwarfs have %.2f rocky planets per star, G stars %.2f",
+			float64(m.rocky)/float64(m.stars), float64(g.rocky)/float64(g.stars))
+	}
+
+	// Giant planets are more common around metal-rich stars
+	if metalRich.stars == 0 || metalPoor.stars == 0 {
+		t.Fatal("Expected metal-rich and metal-poor stars")
+	}
+	rich := float64(metalRich.giantHosts) / float64(metalRich.stars)
+	poor := float64(metalPoor.giantHosts) / float64(metalPoor.stars)
+	if rich <= 2*poor {
+		t.Errorf("%.3f of metal-rich and %.3f of metal-poor stars host giants", rich, poor)
+	}
+
+	// Classes left out of a custom table have no planets
+	cfg.NumStars = 500
+	cfg.Occurrence = &generator.OccurrenceConfig{
+		Rates:                map[string]generator.PlanetKinds{"M": {Rocky: 3}},
+		MetallicityExponents: &generator.PlanetKinds{},
+	}
+	for system := range generator.Stream(context.Background(), cfg) {
+		isM := system.Star.SpectralType[:1] == "M"
+		if !isM && (len(system.Planets) > 0 || len(system.Exoplanets) > 0) {
+			t.Errorf("%s (%s) has planets without an occurrence rate", system.Star.Name, system.Star.SpectralType)
+		}
+		for _, p := range system.Planets {
+			if p.Mass > 5 {
+				t.Errorf("Planet %s of mass %.1f with only rocky planets configured", p.Name, p.Mass)
+			}
+		}
+		for _, exo := range system.Exoplanets {
+			if exo.Mass > 5 {
+				t.Errorf("Exoplanet %s of mass %.1f with only rocky planets configured", exo.Name, exo.Mass)
+			}
+		}
+	}
+}
+
+// systemBodies returns the planets and exoplanets of system as planets, by
+// increasing period
+func systemBodies(system generator.System) []models.Planet {
+	bodies := append([]models.Planet(nil), system.Planets...)
+	for _, exo := range system.Exoplanets {
+		bodies = append(bodies, models.Planet{
+			Name:          exo.Name,
+			SemiMajorAxis: exo.SemiMajorAxis,
+			OrbitalPeriod: exo.OrbitalPeriod,
+			Eccentricity:  exo.Eccentricity,
+			Mass:          exo.Mass,
+		})
+	}
+	sort.Slice(bodies, func(i, j int) bool { return bodies[i].OrbitalPeriod < bodies[j].OrbitalPeriod })
+	return bodies
+}
+
+func TestArchitectures(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:       2000,
+		PlanetsPerStar: 15,
+		ExoPerStar:     8,
+		Seed:           2424,
+		Architectures:  generator.DefaultArchitectures,
+	}
+
+	counts := make(map[string]int)
+	for system := range generator.Stream(context.Background(), cfg) {
+		star := system.Star
+		counts[star.Architecture]++
+
+		// The template is laid out once, as planets and exoplanets
+		bodies := systemBodies(system)
+
+		switch star.Architecture {
+		case generator.ArchitectureHotJupiter:
+			if len(bodies) != 1 {
+				t.Errorf("Hot Jupiter system %s has %d planets and %d exoplanets", star.Name, len(system.Planets), len(system.Exoplanets))
+			}
+			for _, p := range bodies {
+				if p.Mass < 20 || (star.Radius < 10 && p.SemiMajorAxis > 0.1) {
+					t.Errorf("Hot Jupiter %s of mass %.1f at %.3f AU", p.Name, p.Mass, p.SemiMajorAxis)
+				}
+			}
+
+		case generator.ArchitectureCompactMulti:
+			if len(bodies) < 3 || len(bodies) > 7 {
+				t.Errorf("Compact system %s has %d planets and %d exoplanets", star.Name, len(system.Planets), len(system.Exoplanets))
+			}
+			for j, p := range bodies {
+				if p.Mass > 5 {
+					t.Errorf("Compact system planet %s of mass %.1f", p.Name, p.Mass)
+				}
+				if j == 0 {
+					continue
+				}
+				ratio := p.OrbitalPeriod / bodies[j-1].OrbitalPeriod
+				if ratio < 4.0/3 || ratio > 2*1.02 {
+					t.Errorf("Period ratio %.3f of %s to its inner neighbour is not near a resonance", ratio, p.Name)
+				}
+			}
+
+		case generator.ArchitectureDebrisDisk:
+			if len(system.Planets) > 0 || len(system.Exoplanets) > 0 {
+				t.Errorf("Debris disk system %s has %d planets and %d exoplanets", star.Name, len(system.Planets), len(system.Exoplanets))
+			}
+		}
+	}
+
+	// Every built-in template is chosen in proportion to its weight
+	for _, arch := range generator.DefaultArchitectures {
+		fraction := float64(counts[arch.Name]) / float64(cfg.NumStars)
+		if math.Abs(fraction-arch.Weight) > 0.04 {
+			t.Errorf("%.3f of stars have architecture %s with weight %.2f", fraction, arch.Name, arch.Weight)
+		}
+	}
+
+	// Custom templates place planets of their kind within their range
+	cfg.NumStars = 300
+	cfg.Architectures = []generator.Architecture{{
+		Name:   "warm-neptunes",
+		Weight: 1,
+		Planets: []generator.PlanetGroup{
+			{Kind: generator.PlanetKindIce, Count: [2]int{2, 3}, Axis: [2]float64{0.1, 0.5}, Eccentricity: [2]float64{0, 0.1}},
+		},
+	}}
+	for system := range generator.Stream(context.Background(), cfg) {
+		if system.Star.Architecture != "warm-neptunes" {
+			t.Errorf("%s has architecture %q", system.Star.Name, system.Star.Architecture)
+		}
+		bodies := systemBodies(system)
+		if len(bodies) < 2 || len(bodies) > 3 {
+			t.Errorf("%s has %d planets and %d exoplanets", system.Star.Name, len(system.Planets), len(system.Exoplanets))
+		}
+		for _, p := range bodies {
+			if p.Mass < 5 || p.Mass > 20 || p.Eccentricity > 0.1 || (system.Star.Radius < 10 && p.SemiMajorAxis > 0.5) {
+				t.Errorf("Planet %s of mass %.1f at %.3f AU, e = %.2f", p.Name, p.Mass, p.SemiMajorAxis, p.Eccentricity)
+			}
+		}
+	}
+}
+
+func TestMassRadiusRelation(t *testing.T) {
+	cfg := generator.Config{NumStars: 300, PlanetsPerStar: 8, ExoPerStar: 5, Seed: 1818}
+	data := generator.GenerateAll(cfg)
+
+	classes := []struct {
+		class     string
+		maxRadius float64
+	}{
+		{generator.PlanetClassTerrestrial, 1.25},
+		{generator.PlanetClassSuperEarth, 1.75},
+		{generator.PlanetClassSubNeptune, 3.5},
+		{generator.PlanetClassNeptune, 8.0},
+		{generator.PlanetClassJovian, math.Inf(1)},
+	}
+	check := func(name string, mass, radius float64, class string) {
+		// Bulk density in g/cm^3, from puffy giants to iron-rich rocks
+		density := 5.51 * mass / (radius * radius * radius)
+		if density < 0.02 || density > 30 {
+			t.Errorf("%s has implausible density %.3f g/cm^3 (M=%.2f, R=%.2f)", name, density, mass, radius)
+		}
+
+		for _, c := range classes {
+			if radius < c.maxRadius {
+				if class != c.class {
+					t.Errorf("%s with radius %.2f is %q, expected %q", name, radius, class, c.class)
+				}
+				break
+			}
+		}
+	}
+
+	var rocky, giants []float64
+	for _, planet := range data.Planets {
+		check(planet.Name, planet.Mass, planet.Radius, planet.PlanetClass)
+		if planet.Mass < 2 {
+			rocky = append(rocky, planet.Radius/math.Pow(planet.Mass, 0.279))
+		}
+		if planet.Mass > 200 {
+			giants = append(giants, planet.Radius)
+		}
+	}
+	for _, exo := range data.Exoplanets {
+		check(exo.Name, exo.Mass, exo.Radius, exo.PlanetClass)
+	}
+
+	// Rocky planets follow R ≈ M^0.28 and giants are about one Jupiter radius
+	if m := mean(rocky); math.Abs(m-1.0) > 0.05 {
+		t.Errorf("Mean R/M^0.279 of rocky planets %.3f, expected about 1", m)
+	}
+	if m := mean(giants); m < 10 || m > 16 {
+		t.Errorf("Mean gas giant radius %.2f Earth radii, expected 10-16", m)
+	}
+}
+
+func TestClimate(t *testing.T) {
+	cfg := generator.Config{NumStars: 300, PlanetsPerStar: 8, ExoPerStar: 5, Seed: 1919}
+
+	// Earth: T_eq = 5772 * sqrt(0.00465/2) * 0.7^0.25 ≈ 255 K
+	teq := func(star models.Star, a, e, albedo float64) float64 {
+		return float64(star.Temperature) * math.Sqrt(star.Radius*0.00465047/(2*a)) *
+			math.Pow(1-albedo, 0.25) * math.Pow(1-e*e, -0.125)
+	}
+	if earth := teq(models.Star{Temperature: 5772, Radius: 1}, 1, 0.0167, 0.3); math.Abs(earth-255) > 1 {
+		t.Fatalf("Earth equilibrium temperature %.1f K, expected about 255 K", earth)
+	}
+
+	// check compares a planet's climate with its host star
+	check := func(star models.Star, name string, a, e, albedo, flux, eqTemp float64, habitable bool) {
+		if albedo < 0 || albedo >= 1 {
+			t.Errorf("%s has Bond albedo %.3f outside [0, 1)", name, albedo)
+		}
+		if want := star.Luminosity / (a * a * math.Sqrt(1-e*e)); math.Abs(flux-want) > 1e-9*want {
+			t.Errorf("%s has insolation %.4g, expected %.4g", name, flux, want)
+		}
+		if want := teq(star, a, e, albedo); math.Abs(eqTemp-want) > 1e-3*want {
+			t.Errorf("%s has equilibrium temperature %.1f K, expected %.1f K", name, eqTemp, want)
+		}
+
+		// The flux-weighted distance lies within the conservative zone
+		d := math.Sqrt(star.Luminosity / flux)
+		if within := d >= star.HZInner && d <= star.HZOuter; within != habitable {
+			t.Errorf("%s at %.3f AU has InHabitableZone=%t for zone %.3f-%.3f AU",
+				name, d, habitable, star.HZInner, star.HZOuter)
+		}
+	}
+
+	habitable := 0
+	for system := range generator.Stream(context.Background(), cfg) {
+		star := system.Star
+		if !(star.HZOptimisticInner < star.HZInner && star.HZInner < star.HZOuter && star.HZOuter < star.HZOptimisticOuter) {
+			t.Errorf("%s has habitable zone %.3f < %.3f < %.3f < %.3f out of order", star.Name,
+				star.HZOptimisticInner, star.HZInner, star.HZOuter, star.HZOptimisticOuter)
+		}
+
+		for _, p := range system.Planets {
+			check(star, p.Name, p.SemiMajorAxis, p.Eccentricity, p.BondAlbedo, p.Insolation, p.EquilibriumTemp, p.InHabitableZone)
+			if p.SurfaceTemp != int32(math.Round(p.EquilibriumTemp)) {
+				t.Errorf("%s has surface temperature %d K without greenhouse, expected %.0f K", p.Name, p.SurfaceTemp, p.EquilibriumTemp)
+			}
+			if p.InHabitableZone {
+				habitable++
+			}
+		}
+		for _, exo := range system.Exoplanets {
+			check(star, exo.Name, exo.SemiMajorAxis, exo.Eccentricity, exo.BondAlbedo, exo.Insolation, exo.EquilibriumTemp, exo.InHabitableZone)
+			if exo.InHabitableZone {
+				habitable++
+			}
+		}
+	}
+	if habitable == 0 {
+		t.Error("No planets generated in a habitable zone")
+	}
+
+	// The greenhouse effect only ever warms the surface
+	cfg.NumStars = 50
+	cfg.Greenhouse = true
+	for system := range generator.Stream(context.Background(), cfg) {
+		for _, p := range system.Planets {
+			if float64(p.SurfaceTemp) < math.Round(p.EquilibriumTemp) {
+				t.Errorf("%s has surface temperature %d K below equilibrium %.1f K", p.Name, p.SurfaceTemp, p.EquilibriumTemp)
+			}
+		}
+	}
+}
+
+func TestAtmospheres(t *testing.T) {
+	cfg := generator.Config{NumStars: 300, PlanetsPerStar: 8, ExoPerStar: 0, Seed: 2020}
+	without := generator.GenerateAll(cfg)
+
+	cfg.Compositions = true
+	with := generator.GenerateAll(cfg)
+
+	counts := make(map[string]int)
+	for i, p := range with.Planets {
+		counts[p.Atmosphere]++
+		vEsc := 11.186 * math.Sqrt(p.Mass/p.Radius)
+
+		switch p.PlanetClass {
+		case generator.PlanetClassNeptune, generator.PlanetClassJovian:
+			// Giants keep their hydrogen, with methane when cool
+			want := "H2/He with methane"
+			if p.EquilibriumTemp >= 1000 {
+				want = "H2/He dominant"
+			}
+			if p.Atmosphere != want {
+				t.Errorf("%s at %.0f K has %q, expected %q", p.Name, p.EquilibriumTemp, p.Atmosphere, want)
+			}
+		case generator.PlanetClassTerrestrial, generator.PlanetClassSuperEarth:
+			// Rocky planets beyond the cosmic shoreline are bare
+			bare := p.EquilibriumTemp > 42*vEsc
+			if bare != (p.Atmosphere == "No atmosphere") {
+				t.Errorf("%s at %.0f K with v_esc %.1f km/s has %q", p.Name, p.EquilibriumTemp, vEsc, p.Atmosphere)
+			}
+			if strings.HasPrefix(p.Atmosphere, "H2/He") {
+				t.Errorf("Rocky planet %s has %q", p.Name, p.Atmosphere)
+			}
+		}
+
+		// Compositions are normalised and only exist for atmospheres
+		total := 0.0
+		for _, fraction := range p.Composition {
+			if fraction <= 0 || fraction > 1 {
+				t.Errorf("%s has mole fraction %g", p.Name, fraction)
+			}
+			total += fraction
+		}
+		if (p.Atmosphere == "No atmosphere") != (len(p.Composition) == 0) {
+			t.Errorf("%s with %q has composition %v", p.Name, p.Atmosphere, p.Composition)
+		}
+		if len(p.Composition) > 0 && math.Abs(total-1) > 1e-9 {
+			t.Errorf("%s mole fractions sum to %g", p.Name, total)
+		}
+
+		// Compositions draw from their own stream
+		p.Composition = nil
+		if !reflect.DeepEqual(p, without.Planets[i]) {
+			t.Fatalf("Enabling compositions changed %s", p.Name)
+		}
+	}
+
+	for _, atmosphere := range []string{"H2/He dominant", "H2/He with methane", "N2/O2 dominant", "CO2 dominant", "Thin atmosphere"} {
+		if counts[atmosphere] == 0 {
+			t.Errorf("No planets with %q atmospheres generated", atmosphere)
+		}
+	}
+}
+
+func TestMoons(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:       300,
+		PlanetsPerStar: 8,
+		ExoPerStar:     0,
+		Seed:           2222,
+	}
+
+	data := generator.GenerateAll(cfg)
+	if len(data.Moons) == 0 {
+		t.Fatal("No moons generated")
+	}
+
+	stars := make(map[string]models.Star)
+	for _, star := range data.Stars {
+		stars[star.ID] = star
+	}
+	planets := make(map[string]models.Planet)
+	for _, p := range data.Planets {
+		planets[p.ID] = p
+	}
+
+	const earthRadiusKm, kmPerAU, earthGM = 6371.0, 1.495978707e8, 398600.4418
+	moons := make(map[string][]models.Moon)
+	locked := 0
+	for _, moon := range data.Moons {
+		p, ok := planets[moon.PlanetID]
+		if !ok {
+			t.Fatalf("Moon %s references non-existent planet: %s", moon.Name, moon.PlanetID)
+		}
+		moons[p.ID] = append(moons[p.ID], moon)
+
+		// Moons orbit outside the planet and inside its Hill sphere
+		hill := p.SemiMajorAxis * math.Cbrt(p.Mass*3.003e-6/(3*stars[p.StarID].Mass)) * kmPerAU
+		if moon.OrbitalRadius <= p.Radius*earthRadiusKm || moon.OrbitalRadius >= hill/2 {
+			t.Errorf("Moon %s at %.0f km from a planet of radius %.0f km with Hill radius %.0f km",
+				moon.Name, moon.OrbitalRadius, p.Radius*earthRadiusKm, hill)
+		}
+		if moon.Mass <= 0 || moon.Mass >= p.Mass/10 || moon.Radius <= 0 || moon.Radius >= p.Radius {
+			t.Errorf("Moon %s has mass %g and radius %g around a planet of mass %g and radius %g",
+				moon.Name, moon.Mass, moon.Radius, p.Mass, p.Radius)
+		}
+
+		// Kepler's third law around the planet
+		period := 2 * math.Pi * math.Sqrt(math.Pow(moon.OrbitalRadius, 3)/(earthGM*(p.Mass+moon.Mass))) / 86400
+		if math.Abs(moon.OrbitalPeriod-period) > 1e-9*period {
+			t.Errorf("Moon %s has period %.4f days, expected %.4f", moon.Name, moon.OrbitalPeriod, period)
+		}
+		if moon.TidallyLocked {
+			locked++
+		}
+	}
+	if locked == 0 || locked == len(data.Moons) {
+		t.Errorf("%d of %d moons are tidally locked", locked, len(data.Moons))
+	}
+
+	// Moons are numbered from the inside out and HasMoons matches them
+	var giantMoons, rockyMoons, giants, rocky int
+	for _, p := range data.Planets {
+		if p.HasMoons != (len(moons[p.ID]) > 0) {
+			t.Errorf("Planet %s has HasMoons %t with %d moons", p.Name, p.HasMoons, len(moons[p.ID]))
+		}
+		for i, moon := range moons[p.ID] {
+			if moon.Name != fmt.Sprintf("%s-Moon-%d", p.Name, i+1) {
+				t.Errorf("Moon %d of %s is named %s", i+1, p.Name, moon.Name)
+			}
+			if i > 0 && moon.OrbitalRadius < moons[p.ID][i-1].OrbitalRadius {
+				t.Errorf("Moon %s is inside %s", moon.Name, moons[p.ID][i-1].Name)
+			}
+		}
+
+		switch {
+		case p.Mass > 100:
+			giants++
+			giantMoons += len(moons[p.ID])
+		case p.Mass < 2:
+			rocky++
+			rockyMoons += len(moons[p.ID])
+		}
+	}
+
+	// Massive planets have more moons
+	if giants == 0 || rocky == 0 || float64(giantMoons)/float64(giants) <= 2*float64(rockyMoons)/float64(rocky) {
+		t.Errorf("Giants have %d moons per planet and rocky planets %d per planet",
+			giantMoons/max(giants, 1), rockyMoons/max(rocky, 1))
+	}
+}
+
+func TestArchitectureSpacing(t *testing.T) {
+	for _, arch := range generator.DefaultArchitectures {
+		arch.Weight = 1
+		cfg := generator.Config{
+			NumStars:       500,
+			PlanetsPerStar: 15,
+			ExoPerStar:     8,
+			Seed:           2525,
+			OrbitSpacing:   generator.OrbitSpacingHill,
+			Architectures:  []generator.Architecture{arch},
+		}
+
+		for system := range generator.Stream(context.Background(), cfg) {
+			if !generator.IsStable(systemBodies(system)) {
+				t.Errorf("%s: system of %s (%.2f solar masses) is not stable", arch.Name, system.Star.Name, system.Star.Mass)
+			}
+		}
+	}
+}
+
+func TestStableOrbitSpacing(t *testing.T) {
+	for _, spacing := range []string{generator.OrbitSpacingHill, generator.OrbitSpacingPeriodRatio} {
+		cfg := generator.Config{
+			NumStars:       300,
+			PlanetsPerStar: 10,
+			ExoPerStar:     5,
+			Seed:           1111,
+			OrbitSpacing:   spacing,
+		}
+
+		multiPlanet, dropped := 0, 0
+		for system := range generator.Stream(context.Background(), cfg) {
+			if len(system.Planets) > 1 {
+				multiPlanet++
+			}
+			if !generator.IsStable(system.Planets) {
+				t.Errorf("%s: system of %s is not stable", spacing, system.Star.Name)
+			}
+
+			// Exoplanets are spaced together with the planets
+			if !generator.IsStable(systemBodies(system)) {
+				t.Errorf("%s: planets and exoplanets of %s are not stable together", spacing, system.Star.Name)
+			}
+			dropped += system.Dropped
+
+			// Planets are numbered from the inside out
+			for i := 1; i < len(system.Planets); i++ {
+				if system.Planets[i].SemiMajorAxis <= system.Planets[i-1].SemiMajorAxis {
+					t.Errorf("%s: %s is not outside %s", spacing, system.Planets[i].Name, system.Planets[i-1].Name)
+				}
+			}
+		}
+		if multiPlanet == 0 {
+			t.Errorf("%s: no multi-planet systems generated", spacing)
+		}
+		if dropped == 0 {
+			t.Errorf("%s: no planets dropped from systems of up to 15 bodies", spacing)
+		}
+	}
+
+	// Two Jupiters 0.001 AU apart are not stable
+	planets := []models.Planet{
+		{SemiMajorAxis: 1.0, OrbitalPeriod: 365.25, Mass: 318},
+		{SemiMajorAxis: 1.001, OrbitalPeriod: 365.25 * math.Pow(1.001, 1.5), Mass: 318},
+	}
+	if generator.IsStable(planets) {
+		t.Error("IsStable accepted overlapping orbits")
+	}
+}
+
+func TestOrbitalElements(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:       500,
+		PlanetsPerStar: 6,
+		ExoPerStar:     3,
+		Seed:           1212,
+	}
+
+	prograde := 0
+	planets := 0
+	for system := range generator.Stream(context.Background(), cfg) {
+		for _, p := range system.Planets {
+			planets++
+			if p.Inclination < 90 {
+				prograde++
+			}
+			checkAngles(t, p.Name, p.Inclination, p.LongitudeOfAscendingNode, p.ArgumentOfPeriapsis, p.MeanAnomaly, p.Epoch)
+		}
+		for _, exo := range system.Exoplanets {
+			checkAngles(t, exo.Name, exo.Inclination, exo.LongitudeOfAscendingNode, exo.ArgumentOfPeriapsis, exo.MeanAnomaly, exo.Epoch)
+		}
+
+		// Orbits within a system are nearly coplanar
+		for i := 1; i < len(system.Planets); i++ {
+			if d := math.Abs(system.Planets[i].Inclination - system.Planets[0].Inclination); d > 20 {
+				t.Errorf("%s: planets differ in inclination by %f degrees", system.Star.Name, d)
+			}
+		}
+	}
+
+	// Orbital planes are oriented isotropically, so about half are prograde
+	if fraction := float64(prograde) / float64(planets); fraction < 0.4 || fraction > 0.6 {
+		t.Errorf("Fraction of prograde orbits %f, expected about 0.5", fraction)
+	}
+}
+
+func checkAngles(t *testing.T, name string, inclination, node, periapsis, meanAnomaly, epoch float64) {
+	t.Helper()
+	if inclination < 0 || inclination > 180 {
+		t.Errorf("%s has invalid inclination: %f", name, inclination)
+	}
+	for _, angle := range []float64{node, periapsis, meanAnomaly} {
+		if angle < 0 || angle >= 360 {
+			t.Errorf("%s has angle outside [0, 360): %f", name, angle)
+		}
+	}
+	if epoch != 2451545.0 {
+		t.Errorf("%s has epoch %f, expected J2000", name, epoch)
+	}
+}
+
 func TestExoplanetValidation(t *testing.T) {
 	cfg := generator.Config{
 		NumStars:       50,
@@ -192,6 +1212,378 @@ func TestExoplanetValidation(t *testing.T) {
 	}
 }
 
+func TestDetectionMethods(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:   2000,
+		ExoPerStar: 5,
+		Seed:       1616,
+	}
+
+	firstYears := map[string]int32{
+		generator.DetectionTransit:      2002,
+		generator.DetectionRV:           1995,
+		generator.DetectionImaging:      2004,
+		generator.DetectionMicrolensing: 2003,
+		generator.DetectionAstrometry:   2013,
+		generator.DetectionTTV:          2011,
+	}
+
+	axes := make(map[string][]float64)
+	for _, exo := range generator.GenerateAll(cfg).Exoplanets {
+		first, ok := firstYears[exo.DetectionMethod]
+		if !ok {
+			t.Fatalf("Exoplanet %s has unknown detection method %q", exo.Name, exo.DetectionMethod)
+		}
+		if exo.DiscoveryYear < first || exo.DiscoveryYear > 2024 {
+			t.Errorf("%s exoplanet %s discovered in %d, expected %d-2024",
+				exo.DetectionMethod, exo.Name, exo.DiscoveryYear, first)
+		}
+		axes[exo.DetectionMethod] = append(axes[exo.DetectionMethod], exo.SemiMajorAxis)
+	}
+
+	// Transits favour close-in orbits, radial velocity longer ones
+	transit, rv := axes[generator.DetectionTransit], axes[generator.DetectionRV]
+	if len(transit) < 100 || len(rv) < 100 {
+		t.Fatalf("Too few transit (%d) or radial-velocity (%d) exoplanets", len(transit), len(rv))
+	}
+	if mean(transit) >= mean(rv) {
+		t.Errorf("Mean transit semi-major axis %.2f AU not below radial velocity %.2f AU", mean(transit), mean(rv))
+	}
+}
+
+func TestSurveySelection(t *testing.T) {
+	cfg := generator.Config{NumStars: 1000, ExoPerStar: 5, Seed: 1717}
+	truth := generator.GenerateAll(cfg)
+	for _, exo := range truth.Exoplanets {
+		if !exo.Detected {
+			t.Fatalf("Exoplanet %s not detected without survey models", exo.Name)
+		}
+	}
+
+	detectedTransits := func(survey generator.SurveyConfig) int {
+		cfg.Surveys = &survey
+		observed := generator.GenerateAll(cfg)
+
+		count := 0
+		for i, exo := range observed.Exoplanets {
+			// The true population is unchanged
+			want := truth.Exoplanets[i]
+			if exo.ID != want.ID || exo.SemiMajorAxis != want.SemiMajorAxis || exo.Inclination != want.Inclination {
+				t.Fatalf("Survey models changed exoplanet %s", exo.Name)
+			}
+
+			if !exo.Detected {
+				if exo.DetectionMethod != "" || exo.DiscoveryYear != 0 {
+					t.Errorf("Undetected exoplanet %s has method %q and year %d", exo.Name, exo.DetectionMethod, exo.DiscoveryYear)
+				}
+				continue
+			}
+			if exo.DetectionMethod != want.DetectionMethod {
+				t.Errorf("Exoplanet %s detected by %s, expected %s", exo.Name, exo.DetectionMethod, want.DetectionMethod)
+			}
+			if exo.DetectionMethod == generator.DetectionTransit {
+				count++
+			}
+		}
+		return count
+	}
+
+	// Noisier photometry finds fewer transiting planets
+	precise := detectedTransits(generator.SurveyConfig{TransitNoise: 50})
+	noisy := detectedTransits(generator.SurveyConfig{TransitNoise: 500})
+	if precise == 0 || noisy >= precise {
+		t.Errorf("Detected %d transits at 50 ppm and %d at 500 ppm", precise, noisy)
+	}
+}
+
+func TestDesignations(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:       3000,
+		PlanetsPerStar: 5,
+		ExoPerStar:     3,
+		Seed:           2525,
+		Multiplicity:   &generator.MultiplicityConfig{TripleFraction: 0.3},
+		Designations:   true,
+	}
+	data := generator.GenerateAll(cfg)
+
+	// Every star is named after one of its designations, and no two stars
+	// share a designation
+	owners := make(map[string]string)
+	catalogs := make(map[string]map[string]bool)
+	for _, id := range data.StarIdentifiers {
+		if owner, ok := owners[id.Identifier]; ok && owner != id.StarID {
+			t.Errorf("Identifier %s belongs to two stars", id.Identifier)
+		}
+		owners[id.Identifier] = id.StarID
+		if catalogs[id.StarID] == nil {
+			catalogs[id.StarID] = make(map[string]bool)
+		}
+		catalogs[id.StarID][id.Catalog] = true
+	}
+	twoMASS := 0
+	for _, star := range data.Stars {
+		if owners[star.Name] != star.ID {
+			t.Errorf("Star %s is not named after one of its identifiers", star.Name)
+		}
+		if !catalogs[star.ID][generator.CatalogTIC] {
+			t.Errorf("Star %s lacks a TIC identifier", star.Name)
+		}
+		if catalogs[star.ID][generator.Catalog2MASS] {
+			twoMASS++
+		}
+		if strings.HasPrefix(star.Name, "Star-") {
+			t.Errorf("Star %s keeps its generated name", star.Name)
+		}
+	}
+
+	if twoMASS == 0 || twoMASS == len(data.Stars) {
+		t.Errorf("%d of %d stars are in 2MASS, expected only the brighter ones", twoMASS, len(data.Stars))
+	}
+
+	// Planets and exoplanets are lettered together around each host in
+	// discovery order
+	type body struct {
+		name string
+		year int32
+	}
+	hosts := make(map[string][]body)
+	add := func(name string, year int32) {
+		i := strings.LastIndex(name, " ")
+		hosts[name[:i]] = append(hosts[name[:i]], body{name, year})
+	}
+	planetNames := make(map[string]string)
+	for _, p := range data.Planets {
+		add(p.Name, p.DiscoveryYear)
+		planetNames[p.ID] = p.Name
+	}
+	for _, exo := range data.Exoplanets {
+		year := exo.DiscoveryYear
+		if !exo.Detected {
+			year = math.MaxInt32
+		}
+		add(exo.Name, year)
+	}
+	for host, bodies := range hosts {
+		sort.Slice(bodies, func(i, j int) bool { return bodies[i].name < bodies[j].name })
+		for i, b := range bodies {
+			if b.name != fmt.Sprintf("%s %c", host, 'b'+i) {
+				t.Errorf("Planet %s is number %d around %s", b.name, i+1, host)
+			}
+			if i > 0 && b.year < bodies[i-1].year {
+				t.Errorf("Planet %s was discovered before %s", b.name, bodies[i-1].name)
+			}
+		}
+	}
+
+	// Moons are numbered from the inside out in Roman numerals
+	for _, moon := range data.Moons {
+		if !strings.HasPrefix(moon.Name, planetNames[moon.PlanetID]+" ") ||
+			strings.Trim(moon.Name[len(planetNames[moon.PlanetID])+1:], "IVX") != "" {
+			t.Errorf("Moon %s of %s", moon.Name, planetNames[moon.PlanetID])
+		}
+	}
+
+	// Designations do not change anything else
+	cfg.NumStars = 100
+	cfg.Designations = false
+	plain := generator.GenerateAll(cfg)
+	cfg.Designations = true
+	designated := generator.GenerateAll(cfg)
+	for i := range plain.Stars {
+		plain.Stars[i].Name = designated.Stars[i].Name
+	}
+	if !reflect.DeepEqual(plain.Stars, designated.Stars) {
+		t.Error("Designations change the stars")
+	}
+	if len(plain.Planets) != len(designated.Planets) || len(plain.Moons) != len(designated.Moons) {
+		t.Error("Designations change the number of planets or moons")
+	}
+}
+
+func TestDesignatedNamesAcrossShards(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:     40000,
+		ExoPerStar:   3,
+		Seed:         2626,
+		ShardCount:   4,
+		Multiplicity: &generator.MultiplicityConfig{TripleFraction: 0.3},
+		Designations: true,
+	}
+
+	// Faint stars are named too, and no two stars of any shards share a name
+	names := make(map[string]bool)
+	for shard := 0; shard < cfg.ShardCount; shard++ {
+		cfg.ShardIndex = shard
+		for system := range generator.Stream(context.Background(), cfg) {
+			stars := append([]models.Star{system.Star}, system.Companions...)
+			for _, star := range stars {
+				if names[star.Name] {
+					t.Errorf("Shard %d repeats the name %s", shard, star.Name)
+				}
+				names[star.Name] = true
+				if strings.HasPrefix(star.Name, generator.Catalog2MASS) {
+					t.Errorf("Star %s is named after its position", star.Name)
+				}
+			}
+		}
+	}
+}
+
+func TestMultipleSystems(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:       300,
+		PlanetsPerStar: 8,
+		ExoPerStar:     5,
+		Seed:           2121,
+		OrbitSpacing:   generator.OrbitSpacingHill,
+		Multiplicity:   &generator.MultiplicityConfig{TripleFraction: 0.3},
+	}
+
+	data := generator.GenerateAll(cfg)
+	if len(data.StarSystems) == 0 {
+		t.Fatal("No multiple systems generated")
+	}
+
+	stars := make(map[string]models.Star)
+	for _, star := range data.Stars {
+		stars[star.ID] = star
+	}
+
+	triples := 0
+	systems := make(map[string]models.StarSystem)
+	for _, system := range data.StarSystems {
+		systems[system.ID] = system
+		components := map[string]string{"A": system.PrimaryID, "B": system.SecondaryID}
+		if system.Multiplicity == 3 {
+			triples++
+			components["C"] = system.TertiaryID
+			if system.OuterSeparation <= system.Separation {
+				t.Errorf("System %s has outer separation %.2f inside inner separation %.2f", system.Name, system.OuterSeparation, system.Separation)
+			}
+		}
+		for component, id := range components {
+			star, ok := stars[id]
+			if !ok {
+				t.Fatalf("System %s references non-existent star %s", system.Name, id)
+			}
+			if star.SystemID != system.ID || star.Component != component {
+				t.Errorf("Star %s is component %q of %q, expected %q of %s", star.Name, star.Component, star.SystemID, component, system.ID)
+			}
+		}
+		if system.MassRatio <= 0 || system.MassRatio > 1 {
+			t.Errorf("System %s has mass ratio %.3f", system.Name, system.MassRatio)
+		}
+		if system.Eccentricity < 0 || system.Eccentricity >= 1 {
+			t.Errorf("System %s has eccentricity %.3f", system.Name, system.Eccentricity)
+		}
+	}
+	if triples == 0 {
+		t.Error("No triple systems generated")
+	}
+	if len(data.Stars) != cfg.NumStars+len(data.StarSystems)+triples {
+		t.Errorf("Got %d stars for %d primaries, %d systems and %d triples", len(data.Stars), cfg.NumStars, len(data.StarSystems), triples)
+	}
+
+	// S-type orbits lie well inside the binary and P-type orbits outside it
+	orbitTypes := make(map[string]int)
+	hosts := make(map[string][]models.Planet)
+	for _, p := range data.Planets {
+		star, ok := stars[p.StarID]
+		if !ok {
+			t.Fatalf("Planet %s references non-existent star: %s", p.Name, p.StarID)
+		}
+		hosts[p.StarID+p.OrbitType] = append(hosts[p.StarID+p.OrbitType], p)
+		orbitTypes[p.OrbitType]++
+
+		system, multiple := systems[star.SystemID]
+		switch {
+		case !multiple && p.OrbitType != "":
+			t.Errorf("Planet %s of single star has orbit type %q", p.Name, p.OrbitType)
+		case p.OrbitType == generator.OrbitTypeS && p.SemiMajorAxis > 0.464*system.Separation:
+			t.Errorf("S-type planet %s at %.2f AU in binary with separation %.2f AU", p.Name, p.SemiMajorAxis, system.Separation)
+		case p.OrbitType == generator.OrbitTypeP && (star.Component != "A" || p.SemiMajorAxis < system.Separation):
+			t.Errorf("P-type planet %s at %.2f AU around component %s with separation %.2f AU", p.Name, p.SemiMajorAxis, star.Component, system.Separation)
+		}
+
+		// Circumbinary planets receive the light of both stars
+		if p.OrbitType == generator.OrbitTypeP {
+			luminosity := star.Luminosity + stars[system.SecondaryID].Luminosity
+			flux := luminosity / (p.SemiMajorAxis * p.SemiMajorAxis * math.Sqrt(1-p.Eccentricity*p.Eccentricity))
+			if math.Abs(p.Insolation-flux) > 1e-9*flux {
+				t.Errorf("P-type planet %s has insolation %.4f, expected %.4f from both stars", p.Name, p.Insolation, flux)
+			}
+		}
+	}
+	if orbitTypes[generator.OrbitTypeS] == 0 || orbitTypes[generator.OrbitTypeP] == 0 {
+		t.Errorf("Expected both S-type and P-type planets, got %v", orbitTypes)
+	}
+
+	// Each host keeps its planets stable
+	for _, planets := range hosts {
+		if !generator.IsStable(planets) {
+			t.Errorf("Planets of %s (%s) are not stable", stars[planets[0].StarID].Name, planets[0].OrbitType)
+		}
+	}
+
+	for _, exo := range data.Exoplanets {
+		if _, ok := stars[exo.StarID]; !ok {
+			t.Errorf("Exoplanet %s references non-existent star: %s", exo.Name, exo.StarID)
+		}
+	}
+
+	// Orbits no host holds move to another host instead of disappearing:
+	// without spacing, planets keep their numbering without gaps and every
+	// hot Jupiter template keeps its planet
+	hotJupiter, _ := generator.BuiltinArchitecture(generator.ArchitectureHotJupiter)
+	hotJupiter.Weight = 1
+	for _, architectures := range [][]generator.Architecture{nil, {hotJupiter}} {
+		counted := cfg
+		counted.OrbitSpacing = ""
+		counted.Architectures = architectures
+		moved := 0
+		for system := range generator.Stream(context.Background(), counted) {
+			if system.StarSystem == nil {
+				continue
+			}
+			numbers := make(map[string]bool)
+			for _, p := range system.Planets {
+				numbers[p.Name] = true
+				if p.OrbitType == generator.OrbitTypeP || p.SemiMajorAxis > 0.1 {
+					moved++
+				}
+			}
+			for _, exo := range system.Exoplanets {
+				numbers[exo.Name] = true
+			}
+			for i := range system.Planets {
+				if name := fmt.Sprintf("%s-Planet-%d", system.Star.Name, i+1); !numbers[name] && system.Dropped == 0 {
+					t.Errorf("System %s lacks planet %s", system.StarSystem.Name, name)
+				}
+			}
+			for i := range system.Exoplanets {
+				if name := fmt.Sprintf("%s-Exo-%d", system.Star.Name, i+1); !numbers[name] && system.Dropped == 0 {
+					t.Errorf("System %s lacks exoplanet %s", system.StarSystem.Name, name)
+				}
+			}
+			if bodies := len(system.Planets) + len(system.Exoplanets) + system.Dropped; architectures != nil && bodies != 1 {
+				t.Errorf("Hot Jupiter system %s has %d planets, %d exoplanets and %d dropped",
+					system.StarSystem.Name, len(system.Planets), len(system.Exoplanets), system.Dropped)
+			}
+		}
+		if architectures != nil && moved == 0 {
+			t.Error("No hot Jupiter moved to a host with room for it")
+		}
+	}
+
+	// Without a multiplicity model every star is single
+	cfg.Multiplicity = nil
+	single := generator.GenerateAll(cfg)
+	if len(single.Stars) != cfg.NumStars || len(single.StarSystems) != 0 {
+		t.Errorf("Got %d stars and %d systems without multiplicity", len(single.Stars), len(single.StarSystems))
+	}
+}
+
 func TestReferentialIntegrity(t *testing.T) {
 	cfg := generator.Config{
 		NumStars:       20,
@@ -236,3 +1628,18 @@ func BenchmarkGenerateStars(b *testing.B) {
 		generator.GenerateAll(cfg)
 	}
 }
+
+func BenchmarkGenerateStarsParallel(b *testing.B) {
+	cfg := generator.Config{
+		NumStars:       1000,
+		PlanetsPerStar: 8,
+		ExoPerStar:     5,
+		Seed:           12345,
+		Workers:        runtime.NumCPU(),
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		generator.GenerateAll(cfg)
+	}
+}
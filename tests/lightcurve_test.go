@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"djdees/synthetic_stellar_data/generator"
+	"djdees/synthetic_stellar_data/models"
+)
+
+func TestLightCurves(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:       60,
+		PlanetsPerStar: 2,
+		ExoPerStar:     5,
+		Seed:           1414,
+		LightCurves:    &generator.LightCurveConfig{Cadence: time.Hour, Duration: 10 * 24 * time.Hour},
+	}
+	data := generator.GenerateAll(cfg)
+
+	radii := make(map[string]float64)
+	for _, star := range data.Stars {
+		radii[star.ID] = star.Radius
+	}
+	curves := make(map[string][]models.LightCurvePoint)
+	for _, point := range data.LightCurves {
+		curves[point.ExoplanetID] = append(curves[point.ExoplanetID], point)
+	}
+
+	transiting := 0
+	for _, exo := range data.Exoplanets {
+		curve := curves[exo.ID]
+		if exo.DetectionMethod != "Transit" {
+			if len(curve) > 0 {
+				t.Errorf("%s detected by %s has a light curve", exo.Name, exo.DetectionMethod)
+			}
+			continue
+		}
+		transiting++
+
+		// 10 days every hour, both ends included
+		if len(curve) != 241 {
+			t.Fatalf("%s light curve has %d points, expected 241", exo.Name, len(curve))
+		}
+
+		// Noiseless flux is 1 out of transit and dips to roughly (Rp/R*)^2
+		ratio := exo.Radius * 0.009168 / radii[exo.StarID]
+		if ratio > 0.5 {
+			continue
+		}
+		depth := 0.0
+		for i, point := range curve {
+			if i > 0 && point.Time <= curve[i-1].Time {
+				t.Fatalf("%s light curve is not in time order", exo.Name)
+			}
+			if !point.InTransit && point.Flux != 1 {
+				t.Errorf("%s flux %f out of transit, expected 1", exo.Name, point.Flux)
+			}
+			depth = math.Max(depth, 1-point.Flux)
+		}
+		if !curve[len(curve)/2].InTransit {
+			t.Errorf("%s is not in transit at the centre of its light curve", exo.Name)
+		}
+		if depth < 0.5*ratio*ratio || depth > 1.5*ratio*ratio {
+			t.Errorf("%s transit depth %g, expected about %g", exo.Name, depth, ratio*ratio)
+		}
+	}
+
+	if transiting == 0 {
+		t.Fatal("No transiting exoplanets generated")
+	}
+}
+
+func TestLightCurvesDoNotChangeSystems(t *testing.T) {
+	cfg := generator.Config{NumStars: 30, PlanetsPerStar: 3, ExoPerStar: 4, Seed: 99}
+	without := generator.GenerateAll(cfg)
+
+	cfg.LightCurves = &generator.LightCurveConfig{Duration: 24 * time.Hour, Noise: 500}
+	with := generator.GenerateAll(cfg)
+
+	if !reflect.DeepEqual(without.Stars, with.Stars) ||
+		!reflect.DeepEqual(without.Planets, with.Planets) ||
+		!reflect.DeepEqual(without.Exoplanets, with.Exoplanets) {
+		t.Error("Enabling light curves changed the generated systems")
+	}
+	if len(with.LightCurves) == 0 {
+		t.Error("No light curve points generated")
+	}
+	for _, point := range with.LightCurves {
+		if point.FluxError != 500e-6 {
+			t.Fatalf("Flux error %g, expected 500 ppm", point.FluxError)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"math"
+	"testing"
+
+	"djdees/synthetic_stellar_data/generator"
+	"djdees/synthetic_stellar_data/models"
+)
+
+func TestRVObservations(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:         80,
+		PlanetsPerStar:   2,
+		ExoPerStar:       6,
+		Seed:             1515,
+		RadialVelocities: &generator.RVConfig{Observations: 200},
+	}
+	data := generator.GenerateAll(cfg)
+
+	stars := make(map[string]models.Star)
+	for _, star := range data.Stars {
+		stars[star.ID] = star
+	}
+	observations := make(map[string][]models.RVObservation)
+	for _, obs := range data.RVObservations {
+		observations[obs.ExoplanetID] = append(observations[obs.ExoplanetID], obs)
+	}
+
+	// Noiseless measurements are the sum of the signals of the host's planets
+	type epoch struct {
+		star string
+		time float64
+	}
+	totals := make(map[epoch]float64)
+	measured := make(map[epoch]float64)
+	for _, obs := range data.RVObservations {
+		key := epoch{obs.StarID, obs.Time}
+		totals[key] += obs.Signal
+		measured[key] = obs.RadialVelocity
+	}
+	for key, total := range totals {
+		if math.Abs(total-measured[key]) > 1e-9 {
+			t.Fatalf("Measured velocity %f, expected sum of signals %f", measured[key], total)
+		}
+	}
+
+	detected := 0
+	for _, exo := range data.Exoplanets {
+		obs := observations[exo.ID]
+		if exo.DetectionMethod != "Radial Velocity" {
+			if len(obs) > 0 {
+				t.Errorf("%s detected by %s has RV observations", exo.Name, exo.DetectionMethod)
+			}
+			continue
+		}
+		detected++
+
+		if len(obs) != 200 {
+			t.Fatalf("%s has %d RV observations, expected 200", exo.Name, len(obs))
+		}
+
+		// The signal stays within the semi-amplitude K(1+e)
+		star := stars[exo.StarID]
+		e := exo.Eccentricity
+		K := 28.4329 * exo.Mass / 317.828 * math.Sin(exo.Inclination*math.Pi/180) *
+			math.Pow(star.Mass, -2.0/3) * math.Pow(exo.OrbitalPeriod/365.25, -1.0/3) / math.Sqrt(1-e*e)
+		peak := 0.0
+		for i, o := range obs {
+			if o.StarID != exo.StarID {
+				t.Fatalf("%s observation has StarID %s, expected %s", exo.Name, o.StarID, exo.StarID)
+			}
+			if i > 0 && o.Time < obs[i-1].Time {
+				t.Fatalf("%s RV observations are not in time order", exo.Name)
+			}
+			peak = math.Max(peak, math.Abs(o.Signal))
+		}
+		if peak > K*(1+e)*1.001 {
+			t.Errorf("%s RV signal %f m/s exceeds K(1+e) = %f m/s", exo.Name, peak, K*(1+e))
+		}
+	}
+
+	if detected == 0 {
+		t.Fatal("No radial-velocity exoplanets generated")
+	}
+}
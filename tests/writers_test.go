@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"djdees/synthetic_stellar_data/generator"
+	"djdees/synthetic_stellar_data/writers"
+)
+
+// writeDataset generates a dataset with the given seed and writes it in every
+// file-based output format, returning the output directory
+func writeDataset(t *testing.T, seed int64) string {
+	t.Helper()
+
+	cfg := generator.Config{
+		NumStars:         20,
+		PlanetsPerStar:   4,
+		ExoPerStar:       3,
+		Seed:             seed,
+		LightCurves:      &generator.LightCurveConfig{Cadence: time.Hour, Duration: 48 * time.Hour, Noise: 200},
+		RadialVelocities: &generator.RVConfig{Observations: 20, Error: 1, Jitter: 2},
+		Compositions:     true,
+		Multiplicity:     &generator.MultiplicityConfig{TripleFraction: 0.3},
+		Designations:     true,
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	if err := writers.WriteCSV(ctx, generator.Stream(ctx, cfg), dir); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	if err := writers.WriteJSON(ctx, generator.Stream(ctx, cfg), dir); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	if err := writers.WriteParquet(ctx, generator.Stream(ctx, cfg), dir); err != nil {
+		t.Fatalf("WriteParquet failed: %v", err)
+	}
+
+	return dir
+}
+
+func TestOutputByteIdenticalForSameSeed(t *testing.T) {
+	dir1 := writeDataset(t, 42)
+	dir2 := writeDataset(t, 42)
+
+	files := []string{
+		"stars.csv", "planets.csv", "moons.csv", "exoplanets.csv", "light_curves.csv", "rv_observations.csv", "star_systems.csv", "star_identifiers.csv",
+		"stars.json", "planets.json", "moons.json", "exoplanets.json", "light_curves.json", "rv_observations.json", "star_systems.json", "star_identifiers.json",
+		"stars.parquet", "planets.parquet", "moons.parquet", "exoplanets.parquet", "light_curves.parquet", "rv_observations.parquet", "star_systems.parquet", "star_identifiers.parquet",
+	}
+
+	for _, name := range files {
+		b1, err := os.ReadFile(filepath.Join(dir1, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		b2, err := os.ReadFile(filepath.Join(dir2, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if !bytes.Equal(b1, b2) {
+			t.Errorf("%s differs between runs with the same seed", name)
+		}
+	}
+}
+
+func TestStreamedJSONMatchesGenerateAll(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:       15,
+		PlanetsPerStar: 3,
+		ExoPerStar:     2,
+		Seed:           777,
+		Designations:   true,
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	if err := writers.WriteJSON(ctx, generator.Stream(ctx, cfg), dir); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	// Encode the in-memory dataset the way the writer used to
+	data := generator.GenerateAll(cfg)
+	expected := map[string]interface{}{
+		"stars.json":            data.Stars,
+		"star_identifiers.json": data.StarIdentifiers,
+		"planets.json":          data.Planets,
+		"moons.json":            data.Moons,
+		"exoplanets.json":       data.Exoplanets,
+	}
+
+	for name, v := range expected {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(v); err != nil {
+			t.Fatalf("Failed to encode %s: %v", name, err)
+		}
+
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if !bytes.Equal(got, buf.Bytes()) {
+			t.Errorf("Streamed %s differs from encoding the GenerateAll result", name)
+		}
+	}
+}
+
+func TestWriterStopsOnCancel(t *testing.T) {
+	cfg := generator.Config{
+		NumStars:       100000,
+		PlanetsPerStar: 2,
+		ExoPerStar:     2,
+		Seed:           1,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := writers.WriteCSV(ctx, generator.Stream(ctx, cfg), t.TempDir())
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -1,8 +1,10 @@
 package writers
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strings"
 
 	"djdees/synthetic_stellar_data/config"
 	"djdees/synthetic_stellar_data/generator"
@@ -10,12 +12,110 @@ import (
 	gocql "github.com/apache/cassandra-gocql-driver/v2"
 )
 
-// WriteToCassandra writes generated data to Cassandra database
-func WriteToCassandra(data *generator.GeneratedData, configFile string) error {
+// WriteToCassandra writes streamed star systems to Cassandra database
+func WriteToCassandra(ctx context.Context, systems <-chan generator.System, configFile string) error {
+	session, err := openSession(configFile)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	// Create tables
+	if err := createTables(session); err != nil {
+		return err
+	}
+
+	// Insert data
+	log.Println("Inserting stars, planets, moons and exoplanets...")
+	for system := range systems {
+		if err := insertStar(session, system.Star); err != nil {
+			return err
+		}
+
+		for _, companion := range system.Companions {
+			if err := insertStar(session, companion); err != nil {
+				return err
+			}
+		}
+
+		if system.StarSystem != nil {
+			if err := insertStarSystem(session, *system.StarSystem); err != nil {
+				return err
+			}
+		}
+
+		for _, id := range system.StarIdentifiers {
+			if err := insertStarIdentifier(session, id); err != nil {
+				return err
+			}
+		}
+
+		for _, planet := range system.Planets {
+			if err := insertPlanet(session, planet); err != nil {
+				return err
+			}
+		}
+
+		for _, moon := range system.Moons {
+			if err := insertMoon(session, moon); err != nil {
+				return err
+			}
+		}
+
+		for _, exo := range system.Exoplanets {
+			if err := insertExoplanet(session, exo); err != nil {
+				return err
+			}
+		}
+
+		for _, point := range system.LightCurves {
+			if err := insertLightCurvePoint(session, point); err != nil {
+				return err
+			}
+		}
+
+		for _, obs := range system.RVObservations {
+			if err := insertRVObservation(session, obs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return ctx.Err()
+}
+
+// WriteEphemerisToCassandra writes streamed ephemeris points to the
+// ephemeris table, which holds one wide partition per planet
+func WriteEphemerisToCassandra(ctx context.Context, points <-chan models.EphemerisPoint, configFile string) error {
+	session, err := openSession(configFile)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	// Create table
+	if err := createEphemerisTable(session); err != nil {
+		return err
+	}
+
+	// Insert data
+	log.Println("Inserting ephemeris points...")
+	for point := range points {
+		if err := insertEphemerisPoint(session, point); err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+// openSession connects to the configured cluster, creates the keyspace if
+// needed and returns a session bound to it
+func openSession(configFile string) (*gocql.Session, error) {
 	// Load Cassandra configuration
 	cfg, err := config.LoadCassandraConfig(configFile)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
 	// Create cluster configuration
@@ -32,46 +132,24 @@ func WriteToCassandra(data *generator.GeneratedData, configFile string) error {
 	// Create session
 	session, err := cluster.CreateSession()
 	if err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
+		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
-	defer session.Close()
 
 	// Create keyspace
-	if err := createKeyspace(session, cfg); err != nil {
-		return err
+	err = createKeyspace(session, cfg)
+	session.Close()
+	if err != nil {
+		return nil, err
 	}
 
 	// Switch to the keyspace
-	session.Close()
 	cluster.Keyspace = cfg.Keyspace
 	session, err = cluster.CreateSession()
 	if err != nil {
-		return fmt.Errorf("failed to create session with keyspace: %w", err)
-	}
-	defer session.Close()
-
-	// Create tables
-	if err := createTables(session); err != nil {
-		return err
-	}
-
-	// Insert data
-	log.Println("Inserting stars...")
-	if err := insertStars(session, data.Stars); err != nil {
-		return err
-	}
-
-	log.Println("Inserting planets...")
-	if err := insertPlanets(session, data.Planets); err != nil {
-		return err
+		return nil, fmt.Errorf("failed to create session with keyspace: %w", err)
 	}
 
-	log.Println("Inserting exoplanets...")
-	if err := insertExoplanets(session, data.Exoplanets); err != nil {
-		return err
-	}
-
-	return nil
+	return session, nil
 }
 
 func createKeyspace(session *gocql.Session, cfg *config.CassandraConfig) error {
@@ -100,7 +178,25 @@ func createTables(session *gocql.Session) error {
 			spectral_type text,
 			mass double,
 			radius double,
-			temperature int
+			temperature int,
+			age double,
+			metallicity double,
+			luminosity double,
+			surface_gravity double,
+			ra double,
+			dec double,
+			distance double,
+			parallax double,
+			pmra double,
+			pmdec double,
+			radial_velocity double,
+			hz_inner double,
+			hz_outer double,
+			hz_optimistic_inner double,
+			hz_optimistic_outer double,
+			system_id text,
+			component text,
+			architecture text
 		)
 	`
 	if err := session.Query(starsTable).Exec(); err != nil {
@@ -115,13 +211,25 @@ func createTables(session *gocql.Session) error {
 			orbital_period double,
 			semi_major_axis double,
 			eccentricity double,
+			inclination double,
+			longitude_of_ascending_node double,
+			argument_of_periapsis double,
+			mean_anomaly double,
+			epoch double,
+			insolation double,
+			bond_albedo double,
+			equilibrium_temp double,
+			in_habitable_zone boolean,
 			mass double,
 			radius double,
+			planet_class text,
 			atmosphere text,
+			composition text,
 			surface_temp int,
 			has_rings boolean,
 			has_moons boolean,
 			discovery_year int,
+			orbit_type text,
 			star_id text
 		)
 	`
@@ -129,6 +237,42 @@ func createTables(session *gocql.Session) error {
 		return fmt.Errorf("failed to create planets table: %w", err)
 	}
 
+	// Create moons table
+	moonsTable := `
+		CREATE TABLE IF NOT EXISTS moons (
+			id text PRIMARY KEY,
+			name text,
+			orbital_radius double,
+			orbital_period double,
+			mass double,
+			radius double,
+			tidally_locked boolean,
+			planet_id text
+		)
+	`
+	if err := session.Query(moonsTable).Exec(); err != nil {
+		return fmt.Errorf("failed to create moons table: %w", err)
+	}
+
+	// Create moons by planet table, one partition per planet with its
+	// moons from the inside out
+	moonsByPlanetTable := `
+		CREATE TABLE IF NOT EXISTS moons_by_planet (
+			planet_id text,
+			orbital_radius double,
+			id text,
+			name text,
+			orbital_period double,
+			mass double,
+			radius double,
+			tidally_locked boolean,
+			PRIMARY KEY ((planet_id), orbital_radius, id)
+		) WITH CLUSTERING ORDER BY (orbital_radius ASC, id ASC)
+	`
+	if err := session.Query(moonsByPlanetTable).Exec(); err != nil {
+		return fmt.Errorf("failed to create moons by planet table: %w", err)
+	}
+
 	// Create exoplanets table
 	exoplanetsTable := `
 		CREATE TABLE IF NOT EXISTS exoplanets (
@@ -137,12 +281,24 @@ func createTables(session *gocql.Session) error {
 			orbital_period double,
 			semi_major_axis double,
 			eccentricity double,
+			inclination double,
+			longitude_of_ascending_node double,
+			argument_of_periapsis double,
+			mean_anomaly double,
+			epoch double,
+			insolation double,
+			bond_albedo double,
+			equilibrium_temp double,
+			in_habitable_zone boolean,
 			mass double,
 			radius double,
+			planet_class text,
 			detection_method text,
 			host_distance double,
 			surface_temp int,
 			discovery_year int,
+			detected boolean,
+			orbit_type text,
 			star_id text
 		)
 	`
@@ -150,89 +306,440 @@ func createTables(session *gocql.Session) error {
 		return fmt.Errorf("failed to create exoplanets table: %w", err)
 	}
 
+	// Create star systems table
+	starSystemsTable := `
+		CREATE TABLE IF NOT EXISTS star_systems (
+			id text PRIMARY KEY,
+			name text,
+			multiplicity int,
+			primary_id text,
+			secondary_id text,
+			separation double,
+			period double,
+			eccentricity double,
+			mass_ratio double,
+			tertiary_id text,
+			outer_separation double,
+			outer_period double,
+			outer_eccentricity double,
+			outer_mass_ratio double
+		)
+	`
+	if err := session.Query(starSystemsTable).Exec(); err != nil {
+		return fmt.Errorf("failed to create star systems table: %w", err)
+	}
+
+	// Create star identifiers table, one partition per star with its
+	// designations by catalog
+	starIdentifiersTable := `
+		CREATE TABLE IF NOT EXISTS star_identifiers (
+			star_id text,
+			catalog text,
+			identifier text,
+			PRIMARY KEY ((star_id), catalog, identifier)
+		)
+	`
+	if err := session.Query(starIdentifiersTable).Exec(); err != nil {
+		return fmt.Errorf("failed to create star identifiers table: %w", err)
+	}
+
+	// Create stars by identifier table to resolve designations to stars
+	starsByIdentifierTable := `
+		CREATE TABLE IF NOT EXISTS stars_by_identifier (
+			identifier text,
+			star_id text,
+			catalog text,
+			PRIMARY KEY ((identifier), star_id)
+		)
+	`
+	if err := session.Query(starsByIdentifierTable).Exec(); err != nil {
+		return fmt.Errorf("failed to create stars by identifier table: %w", err)
+	}
+
+	// Create light curves table, one partition per exoplanet
+	lightCurvesTable := `
+		CREATE TABLE IF NOT EXISTS light_curves (
+			exoplanet_id text,
+			time double,
+			flux double,
+			flux_error double,
+			in_transit boolean,
+			PRIMARY KEY ((exoplanet_id), time)
+		) WITH CLUSTERING ORDER BY (time ASC)
+	`
+	if err := session.Query(lightCurvesTable).Exec(); err != nil {
+		return fmt.Errorf("failed to create light curves table: %w", err)
+	}
+
+	// Create radial-velocity observations table, one partition per exoplanet
+	rvObservationsTable := `
+		CREATE TABLE IF NOT EXISTS rv_observations (
+			exoplanet_id text,
+			time double,
+			star_id text,
+			radial_velocity double,
+			error double,
+			signal double,
+			PRIMARY KEY ((exoplanet_id), time)
+		) WITH CLUSTERING ORDER BY (time ASC)
+	`
+	if err := session.Query(rvObservationsTable).Exec(); err != nil {
+		return fmt.Errorf("failed to create RV observations table: %w", err)
+	}
+
+	// Tables created by earlier versions lack the columns added since, and
+	// CREATE TABLE IF NOT EXISTS leaves them unchanged
+	for _, table := range []string{starsTable, planetsTable, moonsTable, exoplanetsTable, starSystemsTable} {
+		if err := addMissingColumns(session, table); err != nil {
+			return err
+		}
+	}
+
 	log.Println("Tables created or already exist")
 	return nil
 }
 
-func insertStars(session *gocql.Session, stars []models.Star) error {
-	query := `
-		INSERT INTO stars (id, name, spectral_type, mass, radius, temperature)
-		VALUES (?, ?, ?, ?, ?, ?)
+// addMissingColumns adds the regular columns of the CREATE TABLE statement
+// schema that the existing table lacks. Key columns cannot be added, so
+// tables whose primary key changed must be dropped first.
+func addMissingColumns(session *gocql.Session, schema string) error {
+	lines := strings.Split(strings.TrimSpace(schema), "\n")
+	table := strings.Fields(lines[0])[5]
+
+	// The result metadata lists the table's current columns, even without rows
+	iter := session.Query(fmt.Sprintf("SELECT * FROM %s LIMIT 1", table)).Iter()
+	existing := make(map[string]bool)
+	for _, column := range iter.Columns() {
+		existing[column.Name] = true
+	}
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("failed to read columns of %s table: %w", table, err)
+	}
+
+	for _, line := range lines[1:] {
+		column := strings.Fields(strings.TrimSuffix(strings.TrimSpace(line), ","))
+		if len(column) != 2 || existing[column[0]] {
+			continue
+		}
+		query := fmt.Sprintf("ALTER TABLE %s ADD %s %s", table, column[0], column[1])
+		if err := session.Query(query).Exec(); err != nil {
+			return fmt.Errorf("failed to add column %s to %s table: %w", column[0], table, err)
+		}
+		log.Printf("Added column %s to %s table\n", column[0], table)
+	}
+	return nil
+}
+
+// createEphemerisTable creates the ephemeris table. Each planet's points
+// form one partition, clustered by time.
+func createEphemerisTable(session *gocql.Session) error {
+	ephemerisTable := `
+		CREATE TABLE IF NOT EXISTS ephemeris (
+			planet_id text,
+			timestamp timestamp,
+			x double,
+			y double,
+			z double,
+			vx double,
+			vy double,
+			vz double,
+			PRIMARY KEY ((planet_id), timestamp)
+		) WITH CLUSTERING ORDER BY (timestamp ASC)
 	`
+	if err := session.Query(ephemerisTable).Exec(); err != nil {
+		return fmt.Errorf("failed to create ephemeris table: %w", err)
+	}
+
+	log.Println("Ephemeris table created or already exists")
+	return nil
+}
+
+const insertStarQuery = `
+	INSERT INTO stars (id, name, spectral_type, mass, radius, temperature,
+		age, metallicity, luminosity, surface_gravity,
+		ra, dec, distance, parallax, pmra, pmdec, radial_velocity,
+		hz_inner, hz_outer, hz_optimistic_inner, hz_optimistic_outer, system_id, component,
+		architecture)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+const insertStarSystemQuery = `
+	INSERT INTO star_systems (id, name, multiplicity,
+		primary_id, secondary_id, separation, period, eccentricity, mass_ratio,
+		tertiary_id, outer_separation, outer_period, outer_eccentricity, outer_mass_ratio)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+const insertStarIdentifierQuery = `
+	INSERT INTO star_identifiers (star_id, catalog, identifier)
+	VALUES (?, ?, ?)
+`
+
+const insertStarByIdentifierQuery = `
+	INSERT INTO stars_by_identifier (identifier, star_id, catalog)
+	VALUES (?, ?, ?)
+`
+
+const insertPlanetQuery = `
+	INSERT INTO planets (id, name, orbital_period, semi_major_axis, eccentricity,
+		inclination, longitude_of_ascending_node, argument_of_periapsis, mean_anomaly, epoch,
+		insolation, bond_albedo, equilibrium_temp, in_habitable_zone,
+		mass, radius, planet_class, atmosphere, composition, surface_temp, has_rings, has_moons, discovery_year, orbit_type, star_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+const insertMoonQuery = `
+	INSERT INTO moons (id, name, orbital_radius, orbital_period, mass, radius, tidally_locked, planet_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`
 
+const insertMoonByPlanetQuery = `
+	INSERT INTO moons_by_planet (planet_id, orbital_radius, id, name, orbital_period, mass, radius, tidally_locked)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+const insertExoplanetQuery = `
+	INSERT INTO exoplanets (id, name, orbital_period, semi_major_axis, eccentricity,
+		inclination, longitude_of_ascending_node, argument_of_periapsis, mean_anomaly, epoch,
+		insolation, bond_albedo, equilibrium_temp, in_habitable_zone,
+		mass, radius, planet_class, detection_method, host_distance, surface_temp, discovery_year, detected, orbit_type, star_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+const insertLightCurveQuery = `
+	INSERT INTO light_curves (exoplanet_id, time, flux, flux_error, in_transit)
+	VALUES (?, ?, ?, ?, ?)
+`
+
+const insertRVObservationQuery = `
+	INSERT INTO rv_observations (exoplanet_id, time, star_id, radial_velocity, error, signal)
+	VALUES (?, ?, ?, ?, ?, ?)
+`
+
+const insertEphemerisQuery = `
+	INSERT INTO ephemeris (planet_id, timestamp, x, y, z, vx, vy, vz)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+func insertStar(session *gocql.Session, star models.Star) error {
 	// Use individual inserts instead of batching for Apache driver v2
-	for _, star := range stars {
-		if err := session.Query(query,
-			star.ID,
-			star.Name,
-			star.SpectralType,
-			star.Mass,
-			star.Radius,
-			star.Temperature,
-		).Exec(); err != nil {
-			return fmt.Errorf("failed to insert star %s: %w", star.Name, err)
-		}
+	if err := session.Query(insertStarQuery,
+		star.ID,
+		star.Name,
+		star.SpectralType,
+		star.Mass,
+		star.Radius,
+		star.Temperature,
+		star.Age,
+		star.Metallicity,
+		star.Luminosity,
+		star.SurfaceGravity,
+		star.RA,
+		star.Dec,
+		star.Distance,
+		star.Parallax,
+		star.PMRA,
+		star.PMDec,
+		star.RadialVelocity,
+		star.HZInner,
+		star.HZOuter,
+		star.HZOptimisticInner,
+		star.HZOptimisticOuter,
+		star.SystemID,
+		star.Component,
+		star.Architecture,
+	).Exec(); err != nil {
+		return fmt.Errorf("failed to insert star %s: %w", star.Name, err)
 	}
 
 	return nil
 }
 
-func insertPlanets(session *gocql.Session, planets []models.Planet) error {
-	query := `
-		INSERT INTO planets (id, name, orbital_period, semi_major_axis, eccentricity,
-			mass, radius, atmosphere, surface_temp, has_rings, has_moons, discovery_year, star_id)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
+func insertStarSystem(session *gocql.Session, system models.StarSystem) error {
+	if err := session.Query(insertStarSystemQuery,
+		system.ID,
+		system.Name,
+		system.Multiplicity,
+		system.PrimaryID,
+		system.SecondaryID,
+		system.Separation,
+		system.Period,
+		system.Eccentricity,
+		system.MassRatio,
+		system.TertiaryID,
+		system.OuterSeparation,
+		system.OuterPeriod,
+		system.OuterEccentricity,
+		system.OuterMassRatio,
+	).Exec(); err != nil {
+		return fmt.Errorf("failed to insert star system %s: %w", system.Name, err)
+	}
 
-	// Use individual inserts
-	for _, planet := range planets {
-		if err := session.Query(query,
-			planet.ID,
-			planet.Name,
-			planet.OrbitalPeriod,
-			planet.SemiMajorAxis,
-			planet.Eccentricity,
-			planet.Mass,
-			planet.Radius,
-			planet.Atmosphere,
-			planet.SurfaceTemp,
-			planet.HasRings,
-			planet.HasMoons,
-			planet.DiscoveryYear,
-			planet.StarID,
-		).Exec(); err != nil {
-			return fmt.Errorf("failed to insert planet %s: %w", planet.Name, err)
-		}
+	return nil
+}
+
+// insertStarIdentifier writes a designation to its star's partition of
+// star_identifiers and to stars_by_identifier
+func insertStarIdentifier(session *gocql.Session, id models.StarIdentifier) error {
+	if err := session.Query(insertStarIdentifierQuery,
+		id.StarID,
+		id.Catalog,
+		id.Identifier,
+	).Exec(); err != nil {
+		return fmt.Errorf("failed to insert star identifier %s: %w", id.Identifier, err)
+	}
+
+	if err := session.Query(insertStarByIdentifierQuery,
+		id.Identifier,
+		id.StarID,
+		id.Catalog,
+	).Exec(); err != nil {
+		return fmt.Errorf("failed to insert star %s by identifier: %w", id.Identifier, err)
 	}
 
 	return nil
 }
 
-func insertExoplanets(session *gocql.Session, exoplanets []models.Exoplanet) error {
-	query := `
-		INSERT INTO exoplanets (id, name, orbital_period, semi_major_axis, eccentricity,
-			mass, radius, detection_method, host_distance, surface_temp, discovery_year, star_id)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
+func insertPlanet(session *gocql.Session, planet models.Planet) error {
+	if err := session.Query(insertPlanetQuery,
+		planet.ID,
+		planet.Name,
+		planet.OrbitalPeriod,
+		planet.SemiMajorAxis,
+		planet.Eccentricity,
+		planet.Inclination,
+		planet.LongitudeOfAscendingNode,
+		planet.ArgumentOfPeriapsis,
+		planet.MeanAnomaly,
+		planet.Epoch,
+		planet.Insolation,
+		planet.BondAlbedo,
+		planet.EquilibriumTemp,
+		planet.InHabitableZone,
+		planet.Mass,
+		planet.Radius,
+		planet.PlanetClass,
+		planet.Atmosphere,
+		formatComposition(planet.Composition),
+		planet.SurfaceTemp,
+		planet.HasRings,
+		planet.HasMoons,
+		planet.DiscoveryYear,
+		planet.OrbitType,
+		planet.StarID,
+	).Exec(); err != nil {
+		return fmt.Errorf("failed to insert planet %s: %w", planet.Name, err)
+	}
 
-	// Use individual inserts
-	for _, exo := range exoplanets {
-		if err := session.Query(query,
-			exo.ID,
-			exo.Name,
-			exo.OrbitalPeriod,
-			exo.SemiMajorAxis,
-			exo.Eccentricity,
-			exo.Mass,
-			exo.Radius,
-			exo.DetectionMethod,
-			exo.HostDistance,
-			exo.SurfaceTemp,
-			exo.DiscoveryYear,
-			exo.StarID,
-		).Exec(); err != nil {
-			return fmt.Errorf("failed to insert exoplanet %s: %w", exo.Name, err)
-		}
+	return nil
+}
+
+// insertMoon writes a moon to the moons table and to its planet's
+// partition of moons_by_planet
+func insertMoon(session *gocql.Session, moon models.Moon) error {
+	if err := session.Query(insertMoonQuery,
+		moon.ID,
+		moon.Name,
+		moon.OrbitalRadius,
+		moon.OrbitalPeriod,
+		moon.Mass,
+		moon.Radius,
+		moon.TidallyLocked,
+		moon.PlanetID,
+	).Exec(); err != nil {
+		return fmt.Errorf("failed to insert moon %s: %w", moon.Name, err)
+	}
+
+	if err := session.Query(insertMoonByPlanetQuery,
+		moon.PlanetID,
+		moon.OrbitalRadius,
+		moon.ID,
+		moon.Name,
+		moon.OrbitalPeriod,
+		moon.Mass,
+		moon.Radius,
+		moon.TidallyLocked,
+	).Exec(); err != nil {
+		return fmt.Errorf("failed to insert moon %s by planet: %w", moon.Name, err)
+	}
+
+	return nil
+}
+
+func insertExoplanet(session *gocql.Session, exo models.Exoplanet) error {
+	if err := session.Query(insertExoplanetQuery,
+		exo.ID,
+		exo.Name,
+		exo.OrbitalPeriod,
+		exo.SemiMajorAxis,
+		exo.Eccentricity,
+		exo.Inclination,
+		exo.LongitudeOfAscendingNode,
+		exo.ArgumentOfPeriapsis,
+		exo.MeanAnomaly,
+		exo.Epoch,
+		exo.Insolation,
+		exo.BondAlbedo,
+		exo.EquilibriumTemp,
+		exo.InHabitableZone,
+		exo.Mass,
+		exo.Radius,
+		exo.PlanetClass,
+		exo.DetectionMethod,
+		exo.HostDistance,
+		exo.SurfaceTemp,
+		exo.DiscoveryYear,
+		exo.Detected,
+		exo.OrbitType,
+		exo.StarID,
+	).Exec(); err != nil {
+		return fmt.Errorf("failed to insert exoplanet %s: %w", exo.Name, err)
+	}
+
+	return nil
+}
+
+func insertLightCurvePoint(session *gocql.Session, point models.LightCurvePoint) error {
+	if err := session.Query(insertLightCurveQuery,
+		point.ExoplanetID,
+		point.Time,
+		point.Flux,
+		point.FluxError,
+		point.InTransit,
+	).Exec(); err != nil {
+		return fmt.Errorf("failed to insert light curve point of %s: %w", point.ExoplanetID, err)
+	}
+
+	return nil
+}
+
+func insertRVObservation(session *gocql.Session, obs models.RVObservation) error {
+	if err := session.Query(insertRVObservationQuery,
+		obs.ExoplanetID,
+		obs.Time,
+		obs.StarID,
+		obs.RadialVelocity,
+		obs.Error,
+		obs.Signal,
+	).Exec(); err != nil {
+		return fmt.Errorf("failed to insert RV observation of %s: %w", obs.ExoplanetID, err)
+	}
+
+	return nil
+}
+
+func insertEphemerisPoint(session *gocql.Session, point models.EphemerisPoint) error {
+	if err := session.Query(insertEphemerisQuery,
+		point.PlanetID,
+		point.Timestamp,
+		point.X,
+		point.Y,
+		point.Z,
+		point.VX,
+		point.VY,
+		point.VZ,
+	).Exec(); err != nil {
+		return fmt.Errorf("failed to insert ephemeris point of %s: %w", point.PlanetID, err)
 	}
 
 	return nil
@@ -1,166 +1,403 @@
 package writers
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"djdees/synthetic_stellar_data/generator"
 	"djdees/synthetic_stellar_data/models"
 )
 
-// WriteCSV writes generated data to CSV files
-func WriteCSV(data *generator.GeneratedData, outputDir string) error {
-	// Write stars
-	if err := writeStarsCSV(data.Stars, filepath.Join(outputDir, "stars.csv")); err != nil {
+var starsCSVHeader = []string{
+	"ID", "Name", "SpectralType", "Mass", "Radius", "Temperature",
+	"Age", "Metallicity", "Luminosity", "SurfaceGravity",
+	"RA", "Dec", "Distance", "Parallax", "PMRA", "PMDec", "RadialVelocity",
+	"HZInner", "HZOuter", "HZOptimisticInner", "HZOptimisticOuter",
+	"SystemID", "Component", "Architecture",
+}
+
+var starSystemsCSVHeader = []string{
+	"ID", "Name", "Multiplicity",
+	"PrimaryID", "SecondaryID", "Separation", "Period", "Eccentricity", "MassRatio",
+	"TertiaryID", "OuterSeparation", "OuterPeriod", "OuterEccentricity", "OuterMassRatio",
+}
+
+var starIdentifiersCSVHeader = []string{"StarID", "Catalog", "Identifier"}
+
+var planetsCSVHeader = []string{
+	"ID", "Name", "OrbitalPeriod", "SemiMajorAxis", "Eccentricity",
+	"Inclination", "LongitudeOfAscendingNode", "ArgumentOfPeriapsis", "MeanAnomaly", "Epoch",
+	"Insolation", "BondAlbedo", "EquilibriumTemp", "InHabitableZone",
+	"Mass", "Radius", "PlanetClass", "Atmosphere", "Composition", "SurfaceTemp",
+	"HasRings", "HasMoons", "DiscoveryYear", "OrbitType", "StarID",
+}
+
+var moonsCSVHeader = []string{
+	"ID", "Name", "OrbitalRadius", "OrbitalPeriod", "Mass", "Radius", "TidallyLocked", "PlanetID",
+}
+
+var exoplanetsCSVHeader = []string{
+	"ID", "Name", "OrbitalPeriod", "SemiMajorAxis", "Eccentricity",
+	"Inclination", "LongitudeOfAscendingNode", "ArgumentOfPeriapsis", "MeanAnomaly", "Epoch",
+	"Insolation", "BondAlbedo", "EquilibriumTemp", "InHabitableZone",
+	"Mass", "Radius", "PlanetClass", "DetectionMethod", "HostDistance", "SurfaceTemp",
+	"DiscoveryYear", "Detected", "OrbitType", "StarID",
+}
+
+var lightCurvesCSVHeader = []string{"ExoplanetID", "Time", "Flux", "FluxError", "InTransit"}
+
+var rvObservationsCSVHeader = []string{"ExoplanetID", "StarID", "Time", "RadialVelocity", "Error", "Signal"}
+
+var ephemerisCSVHeader = []string{"PlanetID", "Timestamp", "X", "Y", "Z", "VX", "VY", "VZ"}
+
+// csvFile is an open CSV output file
+type csvFile struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+// createCSV creates a CSV file and writes its header row
+func createCSV(filename string, header []string) (*csvFile, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &csvFile{file: file, writer: csv.NewWriter(file)}
+	if err := f.writer.Write(header); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Close flushes buffered rows and closes the file
+func (f *csvFile) Close() error {
+	f.writer.Flush()
+	if err := f.writer.Error(); err != nil {
+		f.file.Close()
+		return err
+	}
+	return f.file.Close()
+}
+
+// WriteCSV writes streamed star systems to CSV files.
+// Rows are written as systems arrive, so memory use stays bounded.
+func WriteCSV(ctx context.Context, systems <-chan generator.System, outputDir string) (err error) {
+	stars, err := createCSV(filepath.Join(outputDir, "stars.csv"), starsCSVHeader)
+	if err != nil {
 		return fmt.Errorf("failed to write stars CSV: %w", err)
 	}
+	defer closeFile(stars, "stars CSV", &err)
 
-	// Write planets
-	if err := writePlanetsCSV(data.Planets, filepath.Join(outputDir, "planets.csv")); err != nil {
+	starSystems, err := createCSV(filepath.Join(outputDir, "star_systems.csv"), starSystemsCSVHeader)
+	if err != nil {
+		return fmt.Errorf("failed to write star systems CSV: %w", err)
+	}
+	defer closeFile(starSystems, "star systems CSV", &err)
+
+	starIdentifiers, err := createCSV(filepath.Join(outputDir, "star_identifiers.csv"), starIdentifiersCSVHeader)
+	if err != nil {
+		return fmt.Errorf("failed to write star identifiers CSV: %w", err)
+	}
+	defer closeFile(starIdentifiers, "star identifiers CSV", &err)
+
+	planets, err := createCSV(filepath.Join(outputDir, "planets.csv"), planetsCSVHeader)
+	if err != nil {
 		return fmt.Errorf("failed to write planets CSV: %w", err)
 	}
+	defer closeFile(planets, "planets CSV", &err)
+
+	moons, err := createCSV(filepath.Join(outputDir, "moons.csv"), moonsCSVHeader)
+	if err != nil {
+		return fmt.Errorf("failed to write moons CSV: %w", err)
+	}
+	defer closeFile(moons, "moons CSV", &err)
 
-	// Write exoplanets
-	if err := writeExoplanetsCSV(data.Exoplanets, filepath.Join(outputDir, "exoplanets.csv")); err != nil {
+	exoplanets, err := createCSV(filepath.Join(outputDir, "exoplanets.csv"), exoplanetsCSVHeader)
+	if err != nil {
 		return fmt.Errorf("failed to write exoplanets CSV: %w", err)
 	}
+	defer closeFile(exoplanets, "exoplanets CSV", &err)
 
-	return nil
-}
+	lightCurves, err := createCSV(filepath.Join(outputDir, "light_curves.csv"), lightCurvesCSVHeader)
+	if err != nil {
+		return fmt.Errorf("failed to write light curves CSV: %w", err)
+	}
+	defer closeFile(lightCurves, "light curves CSV", &err)
 
-func writeStarsCSV(stars []models.Star, filename string) error {
-	file, err := os.Create(filename)
+	rvObservations, err := createCSV(filepath.Join(outputDir, "rv_observations.csv"), rvObservationsCSVHeader)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to write RV observations CSV: %w", err)
 	}
-	defer func() {
-		if cerr := file.Close(); cerr != nil && err == nil {
-			err = cerr
+	defer closeFile(rvObservations, "RV observations CSV", &err)
+
+	for system := range systems {
+		// Write stars, companions and the system linking them
+		if err := stars.writer.Write(starRecord(system.Star)); err != nil {
+			return fmt.Errorf("failed to write stars CSV: %w", err)
+		}
+		for _, companion := range system.Companions {
+			if err := stars.writer.Write(starRecord(companion)); err != nil {
+				return fmt.Errorf("failed to write stars CSV: %w", err)
+			}
+		}
+		if system.StarSystem != nil {
+			if err := starSystems.writer.Write(starSystemRecord(*system.StarSystem)); err != nil {
+				return fmt.Errorf("failed to write star systems CSV: %w", err)
+			}
+		}
+		for _, id := range system.StarIdentifiers {
+			if err := starIdentifiers.writer.Write(starIdentifierRecord(id)); err != nil {
+				return fmt.Errorf("failed to write star identifiers CSV: %w", err)
+			}
 		}
-	}()
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+		// Write planets
+		for _, planet := range system.Planets {
+			if err := planets.writer.Write(planetRecord(planet)); err != nil {
+				return fmt.Errorf("failed to write planets CSV: %w", err)
+			}
+		}
 
-	// Write header
-	header := []string{"ID", "Name", "SpectralType", "Mass", "Radius", "Temperature"}
-	if err := writer.Write(header); err != nil {
-		return err
-	}
+		// Write moons
+		for _, moon := range system.Moons {
+			if err := moons.writer.Write(moonRecord(moon)); err != nil {
+				return fmt.Errorf("failed to write moons CSV: %w", err)
+			}
+		}
 
-	// Write data
-	for _, star := range stars {
-		record := []string{
-			star.ID,
-			star.Name,
-			star.SpectralType,
-			fmt.Sprintf("%.6f", star.Mass),
-			fmt.Sprintf("%.6f", star.Radius),
-			fmt.Sprintf("%d", star.Temperature),
+		// Write exoplanets
+		for _, exo := range system.Exoplanets {
+			if err := exoplanets.writer.Write(exoplanetRecord(exo)); err != nil {
+				return fmt.Errorf("failed to write exoplanets CSV: %w", err)
+			}
 		}
-		if err := writer.Write(record); err != nil {
-			return err
+
+		// Write light curves
+		for _, point := range system.LightCurves {
+			if err := lightCurves.writer.Write(lightCurveRecord(point)); err != nil {
+				return fmt.Errorf("failed to write light curves CSV: %w", err)
+			}
+		}
+
+		// Write radial-velocity observations
+		for _, obs := range system.RVObservations {
+			if err := rvObservations.writer.Write(rvObservationRecord(obs)); err != nil {
+				return fmt.Errorf("failed to write RV observations CSV: %w", err)
+			}
 		}
 	}
 
-	return nil
+	return ctx.Err()
 }
 
-func writePlanetsCSV(planets []models.Planet, filename string) error {
-	file, err := os.Create(filename)
+// WriteEphemerisCSV writes streamed ephemeris points to ephemeris.csv
+func WriteEphemerisCSV(ctx context.Context, points <-chan models.EphemerisPoint, outputDir string) (err error) {
+	ephemeris, err := createCSV(filepath.Join(outputDir, "ephemeris.csv"), ephemerisCSVHeader)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to write ephemeris CSV: %w", err)
 	}
-	defer func() {
-		if cerr := file.Close(); cerr != nil && err == nil {
-			err = cerr
+	defer closeFile(ephemeris, "ephemeris CSV", &err)
+
+	for point := range points {
+		if err := ephemeris.writer.Write(ephemerisRecord(point)); err != nil {
+			return fmt.Errorf("failed to write ephemeris CSV: %w", err)
 		}
-	}()
+	}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	return ctx.Err()
+}
 
-	// Write header
-	header := []string{
-		"ID", "Name", "OrbitalPeriod", "SemiMajorAxis", "Eccentricity",
-		"Mass", "Radius", "Atmosphere", "SurfaceTemp", "HasRings",
-		"HasMoons", "DiscoveryYear", "StarID",
+func starRecord(star models.Star) []string {
+	return []string{
+		star.ID,
+		star.Name,
+		star.SpectralType,
+		fmt.Sprintf("%.6f", star.Mass),
+		fmt.Sprintf("%.6f", star.Radius),
+		fmt.Sprintf("%d", star.Temperature),
+		fmt.Sprintf("%.6f", star.Age),
+		fmt.Sprintf("%.6f", star.Metallicity),
+		fmt.Sprintf("%.6g", star.Luminosity),
+		fmt.Sprintf("%.6f", star.SurfaceGravity),
+		fmt.Sprintf("%.8f", star.RA),
+		fmt.Sprintf("%.8f", star.Dec),
+		fmt.Sprintf("%.6f", star.Distance),
+		fmt.Sprintf("%.6f", star.Parallax),
+		fmt.Sprintf("%.6f", star.PMRA),
+		fmt.Sprintf("%.6f", star.PMDec),
+		fmt.Sprintf("%.6f", star.RadialVelocity),
+		fmt.Sprintf("%.6f", star.HZInner),
+		fmt.Sprintf("%.6f", star.HZOuter),
+		fmt.Sprintf("%.6f", star.HZOptimisticInner),
+		fmt.Sprintf("%.6f", star.HZOptimisticOuter),
+		star.SystemID,
+		star.Component,
+		star.Architecture,
 	}
-	if err := writer.Write(header); err != nil {
-		return err
+}
+
+func starSystemRecord(system models.StarSystem) []string {
+	return []string{
+		system.ID,
+		system.Name,
+		fmt.Sprintf("%d", system.Multiplicity),
+		system.PrimaryID,
+		system.SecondaryID,
+		fmt.Sprintf("%.6f", system.Separation),
+		fmt.Sprintf("%.6f", system.Period),
+		fmt.Sprintf("%.6f", system.Eccentricity),
+		fmt.Sprintf("%.6f", system.MassRatio),
+		system.TertiaryID,
+		fmt.Sprintf("%.6f", system.OuterSeparation),
+		fmt.Sprintf("%.6f", system.OuterPeriod),
+		fmt.Sprintf("%.6f", system.OuterEccentricity),
+		fmt.Sprintf("%.6f", system.OuterMassRatio),
 	}
+}
 
-	// Write data
-	for _, planet := range planets {
-		record := []string{
-			planet.ID,
-			planet.Name,
-			fmt.Sprintf("%.6f", planet.OrbitalPeriod),
-			fmt.Sprintf("%.6f", planet.SemiMajorAxis),
-			fmt.Sprintf("%.6f", planet.Eccentricity),
-			fmt.Sprintf("%.6f", planet.Mass),
-			fmt.Sprintf("%.6f", planet.Radius),
-			planet.Atmosphere,
-			fmt.Sprintf("%d", planet.SurfaceTemp),
-			fmt.Sprintf("%t", planet.HasRings),
-			fmt.Sprintf("%t", planet.HasMoons),
-			fmt.Sprintf("%d", planet.DiscoveryYear),
-			planet.StarID,
-		}
-		if err := writer.Write(record); err != nil {
-			return err
-		}
+func starIdentifierRecord(id models.StarIdentifier) []string {
+	return []string{id.StarID, id.Catalog, id.Identifier}
+}
+
+func planetRecord(planet models.Planet) []string {
+	return []string{
+		planet.ID,
+		planet.Name,
+		fmt.Sprintf("%.6f", planet.OrbitalPeriod),
+		fmt.Sprintf("%.6f", planet.SemiMajorAxis),
+		fmt.Sprintf("%.6f", planet.Eccentricity),
+		fmt.Sprintf("%.6f", planet.Inclination),
+		fmt.Sprintf("%.6f", planet.LongitudeOfAscendingNode),
+		fmt.Sprintf("%.6f", planet.ArgumentOfPeriapsis),
+		fmt.Sprintf("%.6f", planet.MeanAnomaly),
+		fmt.Sprintf("%.6f", planet.Epoch),
+		fmt.Sprintf("%.6f", planet.Insolation),
+		fmt.Sprintf("%.6f", planet.BondAlbedo),
+		fmt.Sprintf("%.6f", planet.EquilibriumTemp),
+		fmt.Sprintf("%t", planet.InHabitableZone),
+		fmt.Sprintf("%.6f", planet.Mass),
+		fmt.Sprintf("%.6f", planet.Radius),
+		planet.PlanetClass,
+		planet.Atmosphere,
+		formatComposition(planet.Composition),
+		fmt.Sprintf("%d", planet.SurfaceTemp),
+		fmt.Sprintf("%t", planet.HasRings),
+		fmt.Sprintf("%t", planet.HasMoons),
+		fmt.Sprintf("%d", planet.DiscoveryYear),
+		planet.OrbitType,
+		planet.StarID,
 	}
+}
 
-	return nil
+func moonRecord(moon models.Moon) []string {
+	return []string{
+		moon.ID,
+		moon.Name,
+		fmt.Sprintf("%.3f", moon.OrbitalRadius),
+		fmt.Sprintf("%.6f", moon.OrbitalPeriod),
+		fmt.Sprintf("%.6g", moon.Mass),
+		fmt.Sprintf("%.6g", moon.Radius),
+		fmt.Sprintf("%t", moon.TidallyLocked),
+		moon.PlanetID,
+	}
 }
 
-func writeExoplanetsCSV(exoplanets []models.Exoplanet, filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
+// moleculesByAbundance returns the molecules of an atmospheric composition,
+// most abundant first, so that every output lists them in the same order
+func moleculesByAbundance(composition map[string]float64) []string {
+	molecules := make([]string, 0, len(composition))
+	for molecule := range composition {
+		molecules = append(molecules, molecule)
 	}
-	defer func() {
-		if cerr := file.Close(); cerr != nil && err == nil {
-			err = cerr
+	sort.Slice(molecules, func(i, j int) bool {
+		a, b := molecules[i], molecules[j]
+		if composition[a] != composition[b] {
+			return composition[a] > composition[b]
 		}
-	}()
+		return a < b
+	})
+	return molecules
+}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+// formatComposition flattens an atmospheric composition into
+// "molecule:fraction" pairs separated by semicolons, most abundant first
+func formatComposition(composition map[string]float64) string {
+	molecules := moleculesByAbundance(composition)
+	pairs := make([]string, len(molecules))
+	for i, molecule := range molecules {
+		pairs[i] = fmt.Sprintf("%s:%.6f", molecule, composition[molecule])
+	}
+	return strings.Join(pairs, ";")
+}
 
-	// Write header
-	header := []string{
-		"ID", "Name", "OrbitalPeriod", "SemiMajorAxis", "Eccentricity",
-		"Mass", "Radius", "DetectionMethod", "HostDistance", "SurfaceTemp",
-		"DiscoveryYear", "StarID",
+func exoplanetRecord(exo models.Exoplanet) []string {
+	return []string{
+		exo.ID,
+		exo.Name,
+		fmt.Sprintf("%.6f", exo.OrbitalPeriod),
+		fmt.Sprintf("%.6f", exo.SemiMajorAxis),
+		fmt.Sprintf("%.6f", exo.Eccentricity),
+		fmt.Sprintf("%.6f", exo.Inclination),
+		fmt.Sprintf("%.6f", exo.LongitudeOfAscendingNode),
+		fmt.Sprintf("%.6f", exo.ArgumentOfPeriapsis),
+		fmt.Sprintf("%.6f", exo.MeanAnomaly),
+		fmt.Sprintf("%.6f", exo.Epoch),
+		fmt.Sprintf("%.6f", exo.Insolation),
+		fmt.Sprintf("%.6f", exo.BondAlbedo),
+		fmt.Sprintf("%.6f", exo.EquilibriumTemp),
+		fmt.Sprintf("%t", exo.InHabitableZone),
+		fmt.Sprintf("%.6f", exo.Mass),
+		fmt.Sprintf("%.6f", exo.Radius),
+		exo.PlanetClass,
+		exo.DetectionMethod,
+		fmt.Sprintf("%.6f", exo.HostDistance),
+		fmt.Sprintf("%d", exo.SurfaceTemp),
+		fmt.Sprintf("%d", exo.DiscoveryYear),
+		fmt.Sprintf("%t", exo.Detected),
+		exo.OrbitType,
+		exo.StarID,
 	}
-	if err := writer.Write(header); err != nil {
-		return err
+}
+
+func lightCurveRecord(point models.LightCurvePoint) []string {
+	return []string{
+		point.ExoplanetID,
+		fmt.Sprintf("%.6f", point.Time),
+		fmt.Sprintf("%.8f", point.Flux),
+		fmt.Sprintf("%.8f", point.FluxError),
+		fmt.Sprintf("%t", point.InTransit),
 	}
+}
 
-	// Write data
-	for _, exo := range exoplanets {
-		record := []string{
-			exo.ID,
-			exo.Name,
-			fmt.Sprintf("%.6f", exo.OrbitalPeriod),
-			fmt.Sprintf("%.6f", exo.SemiMajorAxis),
-			fmt.Sprintf("%.6f", exo.Eccentricity),
-			fmt.Sprintf("%.6f", exo.Mass),
-			fmt.Sprintf("%.6f", exo.Radius),
-			exo.DetectionMethod,
-			fmt.Sprintf("%.6f", exo.HostDistance),
-			fmt.Sprintf("%d", exo.SurfaceTemp),
-			fmt.Sprintf("%d", exo.DiscoveryYear),
-			exo.StarID,
-		}
-		if err := writer.Write(record); err != nil {
-			return err
-		}
+func rvObservationRecord(obs models.RVObservation) []string {
+	return []string{
+		obs.ExoplanetID,
+		obs.StarID,
+		fmt.Sprintf("%.6f", obs.Time),
+		fmt.Sprintf("%.6f", obs.RadialVelocity),
+		fmt.Sprintf("%.6f", obs.Error),
+		fmt.Sprintf("%.6f", obs.Signal),
 	}
+}
 
-	return nil
+func ephemerisRecord(point models.EphemerisPoint) []string {
+	return []string{
+		point.PlanetID,
+		point.Timestamp.UTC().Format(time.RFC3339),
+		fmt.Sprintf("%.9f", point.X),
+		fmt.Sprintf("%.9f", point.Y),
+		fmt.Sprintf("%.9f", point.Z),
+		fmt.Sprintf("%.9f", point.VX),
+		fmt.Sprintf("%.9f", point.VY),
+		fmt.Sprintf("%.9f", point.VZ),
+	}
 }
@@ -1,51 +1,198 @@
 package writers
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"djdees/synthetic_stellar_data/generator"
+	"djdees/synthetic_stellar_data/models"
 )
 
-// WriteJSON writes generated data to JSON files
-func WriteJSON(data *generator.GeneratedData, outputDir string) error {
-	// Write stars
-	if err := writeJSONFile(data.Stars, filepath.Join(outputDir, "stars.json")); err != nil {
+// jsonArrayFile writes a pretty-printed JSON array one element at a time.
+// The output is identical to encoding the complete slice with an indented
+// json.Encoder.
+type jsonArrayFile struct {
+	file  *os.File
+	buf   *bufio.Writer
+	count int
+}
+
+// createJSONArray creates a JSON array output file
+func createJSONArray(filename string) (*jsonArrayFile, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonArrayFile{file: file, buf: bufio.NewWriter(file)}, nil
+}
+
+// Write appends one element to the array
+func (f *jsonArrayFile) Write(v interface{}) error {
+	data, err := json.MarshalIndent(v, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n  "
+	if f.count == 0 {
+		sep = "[\n  "
+	}
+	f.count++
+
+	if _, err := f.buf.WriteString(sep); err != nil {
+		return err
+	}
+	_, err = f.buf.Write(data)
+	return err
+}
+
+// Close terminates the array, flushes buffered output and closes the file
+func (f *jsonArrayFile) Close() error {
+	end := "\n]\n"
+	if f.count == 0 {
+		end = "[]\n"
+	}
+
+	if _, err := f.buf.WriteString(end); err != nil {
+		f.file.Close()
+		return err
+	}
+	if err := f.buf.Flush(); err != nil {
+		f.file.Close()
+		return err
+	}
+	return f.file.Close()
+}
+
+// WriteJSON writes streamed star systems to JSON files.
+// Elements are written as systems arrive, so memory use stays bounded.
+func WriteJSON(ctx context.Context, systems <-chan generator.System, outputDir string) (err error) {
+	stars, err := createJSONArray(filepath.Join(outputDir, "stars.json"))
+	if err != nil {
 		return fmt.Errorf("failed to write stars JSON: %w", err)
 	}
+	defer closeFile(stars, "stars JSON", &err)
+
+	starSystems, err := createJSONArray(filepath.Join(outputDir, "star_systems.json"))
+	if err != nil {
+		return fmt.Errorf("failed to write star systems JSON: %w", err)
+	}
+	defer closeFile(starSystems, "star systems JSON", &err)
+
+	starIdentifiers, err := createJSONArray(filepath.Join(outputDir, "star_identifiers.json"))
+	if err != nil {
+		return fmt.Errorf("failed to write star identifiers JSON: %w", err)
+	}
+	defer closeFile(starIdentifiers, "star identifiers JSON", &err)
 
-	// Write planets
-	if err := writeJSONFile(data.Planets, filepath.Join(outputDir, "planets.json")); err != nil {
+	planets, err := createJSONArray(filepath.Join(outputDir, "planets.json"))
+	if err != nil {
 		return fmt.Errorf("failed to write planets JSON: %w", err)
 	}
+	defer closeFile(planets, "planets JSON", &err)
+
+	moons, err := createJSONArray(filepath.Join(outputDir, "moons.json"))
+	if err != nil {
+		return fmt.Errorf("failed to write moons JSON: %w", err)
+	}
+	defer closeFile(moons, "moons JSON", &err)
 
-	// Write exoplanets
-	if err := writeJSONFile(data.Exoplanets, filepath.Join(outputDir, "exoplanets.json")); err != nil {
+	exoplanets, err := createJSONArray(filepath.Join(outputDir, "exoplanets.json"))
+	if err != nil {
 		return fmt.Errorf("failed to write exoplanets JSON: %w", err)
 	}
+	defer closeFile(exoplanets, "exoplanets JSON", &err)
 
-	return nil
-}
+	lightCurves, err := createJSONArray(filepath.Join(outputDir, "light_curves.json"))
+	if err != nil {
+		return fmt.Errorf("failed to write light curves JSON: %w", err)
+	}
+	defer closeFile(lightCurves, "light curves JSON", &err)
 
-func writeJSONFile(data interface{}, filename string) error {
-	file, err := os.Create(filename)
+	rvObservations, err := createJSONArray(filepath.Join(outputDir, "rv_observations.json"))
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to write RV observations JSON: %w", err)
 	}
-	defer func() {
-		if cerr := file.Close(); cerr != nil && err == nil {
-			err = cerr
+	defer closeFile(rvObservations, "RV observations JSON", &err)
+
+	for system := range systems {
+		// Write stars, companions and the system linking them
+		if err := stars.Write(system.Star); err != nil {
+			return fmt.Errorf("failed to write stars JSON: %w", err)
+		}
+		for _, companion := range system.Companions {
+			if err := stars.Write(companion); err != nil {
+				return fmt.Errorf("failed to write stars JSON: %w", err)
+			}
+		}
+		if system.StarSystem != nil {
+			if err := starSystems.Write(system.StarSystem); err != nil {
+				return fmt.Errorf("failed to write star systems JSON: %w", err)
+			}
+		}
+		for _, id := range system.StarIdentifiers {
+			if err := starIdentifiers.Write(id); err != nil {
+				return fmt.Errorf("failed to write star identifiers JSON: %w", err)
+			}
+		}
+
+		// Write planets
+		for _, planet := range system.Planets {
+			if err := planets.Write(planet); err != nil {
+				return fmt.Errorf("failed to write planets JSON: %w", err)
+			}
 		}
-	}()
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
+		// Write moons
+		for _, moon := range system.Moons {
+			if err := moons.Write(moon); err != nil {
+				return fmt.Errorf("failed to write moons JSON: %w", err)
+			}
+		}
 
-	if err := encoder.Encode(data); err != nil {
-		return err
+		// Write exoplanets
+		for _, exo := range system.Exoplanets {
+			if err := exoplanets.Write(exo); err != nil {
+				return fmt.Errorf("failed to write exoplanets JSON: %w", err)
+			}
+		}
+
+		// Write light curves
+		for _, point := range system.LightCurves {
+			if err := lightCurves.Write(point); err != nil {
+				return fmt.Errorf("failed to write light curves JSON: %w", err)
+			}
+		}
+
+		// Write radial-velocity observations
+		for _, obs := range system.RVObservations {
+			if err := rvObservations.Write(obs); err != nil {
+				return fmt.Errorf("failed to write RV observations JSON: %w", err)
+			}
+		}
+	}
+
+	return ctx.Err()
+}
+
+// WriteEphemerisJSON writes streamed ephemeris points to ephemeris.json
+func WriteEphemerisJSON(ctx context.Context, points <-chan models.EphemerisPoint, outputDir string) (err error) {
+	ephemeris, err := createJSONArray(filepath.Join(outputDir, "ephemeris.json"))
+	if err != nil {
+		return fmt.Errorf("failed to write ephemeris JSON: %w", err)
+	}
+	defer closeFile(ephemeris, "ephemeris JSON", &err)
+
+	for point := range points {
+		if err := ephemeris.Write(point); err != nil {
+			return fmt.Errorf("failed to write ephemeris JSON: %w", err)
+		}
 	}
 
-	return nil
+	return ctx.Err()
 }
@@ -1,12 +1,14 @@
 package writers
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 
 	"djdees/synthetic_stellar_data/generator"
 	"djdees/synthetic_stellar_data/models"
 	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/source"
 	"github.com/xitongsys/parquet-go/writer"
 )
 
@@ -18,6 +20,54 @@ type StarParquet struct {
 	Mass         float64 `parquet:"name=mass, type=DOUBLE"`
 	Radius       float64 `parquet:"name=radius, type=DOUBLE"`
 	Temperature  int32   `parquet:"name=temperature, type=INT32"`
+
+	Age            float64 `parquet:"name=age, type=DOUBLE"`
+	Metallicity    float64 `parquet:"name=metallicity, type=DOUBLE"`
+	Luminosity     float64 `parquet:"name=luminosity, type=DOUBLE"`
+	SurfaceGravity float64 `parquet:"name=surface_gravity, type=DOUBLE"`
+
+	RA             float64 `parquet:"name=ra, type=DOUBLE"`
+	Dec            float64 `parquet:"name=dec, type=DOUBLE"`
+	Distance       float64 `parquet:"name=distance, type=DOUBLE"`
+	Parallax       float64 `parquet:"name=parallax, type=DOUBLE"`
+	PMRA           float64 `parquet:"name=pmra, type=DOUBLE"`
+	PMDec          float64 `parquet:"name=pmdec, type=DOUBLE"`
+	RadialVelocity float64 `parquet:"name=radial_velocity, type=DOUBLE"`
+
+	HZInner           float64 `parquet:"name=hz_inner, type=DOUBLE"`
+	HZOuter           float64 `parquet:"name=hz_outer, type=DOUBLE"`
+	HZOptimisticInner float64 `parquet:"name=hz_optimistic_inner, type=DOUBLE"`
+	HZOptimisticOuter float64 `parquet:"name=hz_optimistic_outer, type=DOUBLE"`
+
+	SystemID  string `parquet:"name=system_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Component string `parquet:"name=component, type=BYTE_ARRAY, convertedtype=UTF8"`
+
+	Architecture string `parquet:"name=architecture, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+type StarSystemParquet struct {
+	ID           string `parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Name         string `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Multiplicity int32  `parquet:"name=multiplicity, type=INT32"`
+
+	PrimaryID    string  `parquet:"name=primary_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SecondaryID  string  `parquet:"name=secondary_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Separation   float64 `parquet:"name=separation, type=DOUBLE"`
+	Period       float64 `parquet:"name=period, type=DOUBLE"`
+	Eccentricity float64 `parquet:"name=eccentricity, type=DOUBLE"`
+	MassRatio    float64 `parquet:"name=mass_ratio, type=DOUBLE"`
+
+	TertiaryID        string  `parquet:"name=tertiary_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	OuterSeparation   float64 `parquet:"name=outer_separation, type=DOUBLE"`
+	OuterPeriod       float64 `parquet:"name=outer_period, type=DOUBLE"`
+	OuterEccentricity float64 `parquet:"name=outer_eccentricity, type=DOUBLE"`
+	OuterMassRatio    float64 `parquet:"name=outer_mass_ratio, type=DOUBLE"`
+}
+
+type StarIdentifierParquet struct {
+	StarID     string `parquet:"name=star_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Catalog    string `parquet:"name=catalog, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Identifier string `parquet:"name=identifier, type=BYTE_ARRAY, convertedtype=UTF8"`
 }
 
 type PlanetParquet struct {
@@ -26,171 +76,449 @@ type PlanetParquet struct {
 	OrbitalPeriod float64 `parquet:"name=orbital_period, type=DOUBLE"`
 	SemiMajorAxis float64 `parquet:"name=semi_major_axis, type=DOUBLE"`
 	Eccentricity  float64 `parquet:"name=eccentricity, type=DOUBLE"`
+
+	Inclination              float64 `parquet:"name=inclination, type=DOUBLE"`
+	LongitudeOfAscendingNode float64 `parquet:"name=longitude_of_ascending_node, type=DOUBLE"`
+	ArgumentOfPeriapsis      float64 `parquet:"name=argument_of_periapsis, type=DOUBLE"`
+	MeanAnomaly              float64 `parquet:"name=mean_anomaly, type=DOUBLE"`
+	Epoch                    float64 `parquet:"name=epoch, type=DOUBLE"`
+
+	Insolation      float64 `parquet:"name=insolation, type=DOUBLE"`
+	BondAlbedo      float64 `parquet:"name=bond_albedo, type=DOUBLE"`
+	EquilibriumTemp float64 `parquet:"name=equilibrium_temp, type=DOUBLE"`
+	InHabitableZone bool    `parquet:"name=in_habitable_zone, type=BOOLEAN"`
+
+	Mass          float64      `parquet:"name=mass, type=DOUBLE"`
+	Radius        float64      `parquet:"name=radius, type=DOUBLE"`
+	PlanetClass   string       `parquet:"name=planet_class, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Atmosphere    string       `parquet:"name=atmosphere, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Composition   []GasParquet `parquet:"name=composition, type=LIST"`
+	SurfaceTemp   int32        `parquet:"name=surface_temp, type=INT32"`
+	HasRings      bool         `parquet:"name=has_rings, type=BOOLEAN"`
+	HasMoons      bool         `parquet:"name=has_moons, type=BOOLEAN"`
+	DiscoveryYear int32        `parquet:"name=discovery_year, type=INT32"`
+	OrbitType     string       `parquet:"name=orbit_type, type=BYTE_ARRAY, convertedtype=UTF8"`
+	StarID        string       `parquet:"name=star_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+// GasParquet is one molecule of a planet's atmospheric composition. The
+// composition is stored as a list, most abundant first, rather than a map
+// so that the file does not depend on map iteration order.
+type GasParquet struct {
+	Molecule string  `parquet:"name=molecule, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Fraction float64 `parquet:"name=fraction, type=DOUBLE"`
+}
+
+type MoonParquet struct {
+	ID            string  `parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Name          string  `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	OrbitalRadius float64 `parquet:"name=orbital_radius, type=DOUBLE"`
+	OrbitalPeriod float64 `parquet:"name=orbital_period, type=DOUBLE"`
 	Mass          float64 `parquet:"name=mass, type=DOUBLE"`
 	Radius        float64 `parquet:"name=radius, type=DOUBLE"`
-	Atmosphere    string  `parquet:"name=atmosphere, type=BYTE_ARRAY, convertedtype=UTF8"`
-	SurfaceTemp   int32   `parquet:"name=surface_temp, type=INT32"`
-	HasRings      bool    `parquet:"name=has_rings, type=BOOLEAN"`
-	HasMoons      bool    `parquet:"name=has_moons, type=BOOLEAN"`
-	DiscoveryYear int32   `parquet:"name=discovery_year, type=INT32"`
-	StarID        string  `parquet:"name=star_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	TidallyLocked bool    `parquet:"name=tidally_locked, type=BOOLEAN"`
+	PlanetID      string  `parquet:"name=planet_id, type=BYTE_ARRAY, convertedtype=UTF8"`
 }
 
 type ExoplanetParquet struct {
-	ID              string  `parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
-	Name            string  `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
-	OrbitalPeriod   float64 `parquet:"name=orbital_period, type=DOUBLE"`
-	SemiMajorAxis   float64 `parquet:"name=semi_major_axis, type=DOUBLE"`
-	Eccentricity    float64 `parquet:"name=eccentricity, type=DOUBLE"`
+	ID            string  `parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Name          string  `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	OrbitalPeriod float64 `parquet:"name=orbital_period, type=DOUBLE"`
+	SemiMajorAxis float64 `parquet:"name=semi_major_axis, type=DOUBLE"`
+	Eccentricity  float64 `parquet:"name=eccentricity, type=DOUBLE"`
+
+	Inclination              float64 `parquet:"name=inclination, type=DOUBLE"`
+	LongitudeOfAscendingNode float64 `parquet:"name=longitude_of_ascending_node, type=DOUBLE"`
+	ArgumentOfPeriapsis      float64 `parquet:"name=argument_of_periapsis, type=DOUBLE"`
+	MeanAnomaly              float64 `parquet:"name=mean_anomaly, type=DOUBLE"`
+	Epoch                    float64 `parquet:"name=epoch, type=DOUBLE"`
+
+	Insolation      float64 `parquet:"name=insolation, type=DOUBLE"`
+	BondAlbedo      float64 `parquet:"name=bond_albedo, type=DOUBLE"`
+	EquilibriumTemp float64 `parquet:"name=equilibrium_temp, type=DOUBLE"`
+	InHabitableZone bool    `parquet:"name=in_habitable_zone, type=BOOLEAN"`
+
 	Mass            float64 `parquet:"name=mass, type=DOUBLE"`
 	Radius          float64 `parquet:"name=radius, type=DOUBLE"`
+	PlanetClass     string  `parquet:"name=planet_class, type=BYTE_ARRAY, convertedtype=UTF8"`
 	DetectionMethod string  `parquet:"name=detection_method, type=BYTE_ARRAY, convertedtype=UTF8"`
 	HostDistance    float64 `parquet:"name=host_distance, type=DOUBLE"`
 	SurfaceTemp     int32   `parquet:"name=surface_temp, type=INT32"`
 	DiscoveryYear   int32   `parquet:"name=discovery_year, type=INT32"`
+	Detected        bool    `parquet:"name=detected, type=BOOLEAN"`
+	OrbitType       string  `parquet:"name=orbit_type, type=BYTE_ARRAY, convertedtype=UTF8"`
 	StarID          string  `parquet:"name=star_id, type=BYTE_ARRAY, convertedtype=UTF8"`
 }
 
-// WriteParquet writes generated data to Parquet files
-func WriteParquet(data *generator.GeneratedData, outputDir string) error {
-	// Write stars
-	if err := writeStarsParquet(data.Stars, filepath.Join(outputDir, "stars.parquet")); err != nil {
+type LightCurveParquet struct {
+	ExoplanetID string  `parquet:"name=exoplanet_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Time        float64 `parquet:"name=time, type=DOUBLE"`
+	Flux        float64 `parquet:"name=flux, type=DOUBLE"`
+	FluxError   float64 `parquet:"name=flux_error, type=DOUBLE"`
+	InTransit   bool    `parquet:"name=in_transit, type=BOOLEAN"`
+}
+
+type RVObservationParquet struct {
+	ExoplanetID    string  `parquet:"name=exoplanet_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	StarID         string  `parquet:"name=star_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Time           float64 `parquet:"name=time, type=DOUBLE"`
+	RadialVelocity float64 `parquet:"name=radial_velocity, type=DOUBLE"`
+	Error          float64 `parquet:"name=error, type=DOUBLE"`
+	Signal         float64 `parquet:"name=signal, type=DOUBLE"`
+}
+
+type EphemerisParquet struct {
+	PlanetID  string  `parquet:"name=planet_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Timestamp int64   `parquet:"name=timestamp, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	X         float64 `parquet:"name=x, type=DOUBLE"`
+	Y         float64 `parquet:"name=y, type=DOUBLE"`
+	Z         float64 `parquet:"name=z, type=DOUBLE"`
+	VX        float64 `parquet:"name=vx, type=DOUBLE"`
+	VY        float64 `parquet:"name=vy, type=DOUBLE"`
+	VZ        float64 `parquet:"name=vz, type=DOUBLE"`
+}
+
+// parquetFile is an open Parquet output file
+type parquetFile struct {
+	file   source.ParquetFile
+	writer *writer.ParquetWriter
+}
+
+// createParquet creates a Parquet file using the schema of obj
+func createParquet(filename string, obj interface{}) (*parquetFile, error) {
+	fw, err := local.NewLocalFileWriter(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	pw, err := writer.NewParquetWriter(fw, obj, 4)
+	if err != nil {
+		fw.Close()
+		return nil, err
+	}
+
+	return &parquetFile{file: fw, writer: pw}, nil
+}
+
+// Close writes the Parquet footer and closes the file
+func (f *parquetFile) Close() error {
+	if err := f.writer.WriteStop(); err != nil {
+		f.file.Close()
+		return err
+	}
+	return f.file.Close()
+}
+
+// WriteParquet writes streamed star systems to Parquet files.
+// Rows are written as systems arrive, so memory use stays bounded.
+func WriteParquet(ctx context.Context, systems <-chan generator.System, outputDir string) (err error) {
+	stars, err := createParquet(filepath.Join(outputDir, "stars.parquet"), new(StarParquet))
+	if err != nil {
 		return fmt.Errorf("failed to write stars parquet: %w", err)
 	}
+	defer closeFile(stars, "stars parquet", &err)
+
+	starSystems, err := createParquet(filepath.Join(outputDir, "star_systems.parquet"), new(StarSystemParquet))
+	if err != nil {
+		return fmt.Errorf("failed to write star systems parquet: %w", err)
+	}
+	defer closeFile(starSystems, "star systems parquet", &err)
+
+	starIdentifiers, err := createParquet(filepath.Join(outputDir, "star_identifiers.parquet"), new(StarIdentifierParquet))
+	if err != nil {
+		return fmt.Errorf("failed to write star identifiers parquet: %w", err)
+	}
+	defer closeFile(starIdentifiers, "star identifiers parquet", &err)
 
-	// Write planets
-	if err := writePlanetsParquet(data.Planets, filepath.Join(outputDir, "planets.parquet")); err != nil {
+	planets, err := createParquet(filepath.Join(outputDir, "planets.parquet"), new(PlanetParquet))
+	if err != nil {
 		return fmt.Errorf("failed to write planets parquet: %w", err)
 	}
+	defer closeFile(planets, "planets parquet", &err)
 
-	// Write exoplanets
-	if err := writeExoplanetsParquet(data.Exoplanets, filepath.Join(outputDir, "exoplanets.parquet")); err != nil {
-		return fmt.Errorf("failed to write exoplanets parquet: %w", err)
+	moons, err := createParquet(filepath.Join(outputDir, "moons.parquet"), new(MoonParquet))
+	if err != nil {
+		return fmt.Errorf("failed to write moons parquet: %w", err)
 	}
+	defer closeFile(moons, "moons parquet", &err)
 
-	return nil
-}
+	exoplanets, err := createParquet(filepath.Join(outputDir, "exoplanets.parquet"), new(ExoplanetParquet))
+	if err != nil {
+		return fmt.Errorf("failed to write exoplanets parquet: %w", err)
+	}
+	defer closeFile(exoplanets, "exoplanets parquet", &err)
 
-func writeStarsParquet(stars []models.Star, filename string) error {
-	fw, err := local.NewLocalFileWriter(filename)
+	lightCurves, err := createParquet(filepath.Join(outputDir, "light_curves.parquet"), new(LightCurveParquet))
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to write light curves parquet: %w", err)
 	}
-	defer func() {
-		if cerr := fw.Close(); cerr != nil && err == nil {
-			err = cerr
-		}
-	}()
+	defer closeFile(lightCurves, "light curves parquet", &err)
 
-	pw, err := writer.NewParquetWriter(fw, new(StarParquet), 4)
+	rvObservations, err := createParquet(filepath.Join(outputDir, "rv_observations.parquet"), new(RVObservationParquet))
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to write RV observations parquet: %w", err)
 	}
+	defer closeFile(rvObservations, "RV observations parquet", &err)
 
-	for _, star := range stars {
-		s := StarParquet{
-			ID:           star.ID,
-			Name:         star.Name,
-			SpectralType: star.SpectralType,
-			Mass:         star.Mass,
-			Radius:       star.Radius,
-			Temperature:  star.Temperature,
+	for system := range systems {
+		// Write stars, companions and the system linking them
+		if err := stars.writer.Write(starParquet(system.Star)); err != nil {
+			return fmt.Errorf("failed to write stars parquet: %w", err)
 		}
-		if err := pw.Write(s); err != nil {
-			return err
+		for _, companion := range system.Companions {
+			if err := stars.writer.Write(starParquet(companion)); err != nil {
+				return fmt.Errorf("failed to write stars parquet: %w", err)
+			}
+		}
+		if system.StarSystem != nil {
+			if err := starSystems.writer.Write(starSystemParquet(*system.StarSystem)); err != nil {
+				return fmt.Errorf("failed to write star systems parquet: %w", err)
+			}
+		}
+		for _, id := range system.StarIdentifiers {
+			if err := starIdentifiers.writer.Write(starIdentifierParquet(id)); err != nil {
+				return fmt.Errorf("failed to write star identifiers parquet: %w", err)
+			}
 		}
-	}
 
-	if err := pw.WriteStop(); err != nil {
-		return err
+		// Write planets
+		for _, planet := range system.Planets {
+			if err := planets.writer.Write(planetParquet(planet)); err != nil {
+				return fmt.Errorf("failed to write planets parquet: %w", err)
+			}
+		}
+
+		// Write moons
+		for _, moon := range system.Moons {
+			if err := moons.writer.Write(moonParquet(moon)); err != nil {
+				return fmt.Errorf("failed to write moons parquet: %w", err)
+			}
+		}
+
+		// Write exoplanets
+		for _, exo := range system.Exoplanets {
+			if err := exoplanets.writer.Write(exoplanetParquet(exo)); err != nil {
+				return fmt.Errorf("failed to write exoplanets parquet: %w", err)
+			}
+		}
+
+		// Write light curves
+		for _, point := range system.LightCurves {
+			if err := lightCurves.writer.Write(lightCurveParquet(point)); err != nil {
+				return fmt.Errorf("failed to write light curves parquet: %w", err)
+			}
+		}
+
+		// Write radial-velocity observations
+		for _, obs := range system.RVObservations {
+			if err := rvObservations.writer.Write(rvObservationParquet(obs)); err != nil {
+				return fmt.Errorf("failed to write RV observations parquet: %w", err)
+			}
+		}
 	}
 
-	return nil
+	return ctx.Err()
 }
 
-func writePlanetsParquet(planets []models.Planet, filename string) error {
-	fw, err := local.NewLocalFileWriter(filename)
+// WriteEphemerisParquet writes streamed ephemeris points to ephemeris.parquet
+func WriteEphemerisParquet(ctx context.Context, points <-chan models.EphemerisPoint, outputDir string) (err error) {
+	ephemeris, err := createParquet(filepath.Join(outputDir, "ephemeris.parquet"), new(EphemerisParquet))
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to write ephemeris parquet: %w", err)
 	}
-	defer func() {
-		if cerr := fw.Close(); cerr != nil && err == nil {
-			err = cerr
+	defer closeFile(ephemeris, "ephemeris parquet", &err)
+
+	for point := range points {
+		if err := ephemeris.writer.Write(ephemerisParquet(point)); err != nil {
+			return fmt.Errorf("failed to write ephemeris parquet: %w", err)
 		}
-	}()
+	}
 
-	pw, err := writer.NewParquetWriter(fw, new(PlanetParquet), 4)
-	if err != nil {
-		return err
+	return ctx.Err()
+}
+
+func starParquet(star models.Star) StarParquet {
+	return StarParquet{
+		ID:           star.ID,
+		Name:         star.Name,
+		SpectralType: star.SpectralType,
+		Mass:         star.Mass,
+		Radius:       star.Radius,
+		Temperature:  star.Temperature,
+
+		Age:            star.Age,
+		Metallicity:    star.Metallicity,
+		Luminosity:     star.Luminosity,
+		SurfaceGravity: star.SurfaceGravity,
+
+		RA:             star.RA,
+		Dec:            star.Dec,
+		Distance:       star.Distance,
+		Parallax:       star.Parallax,
+		PMRA:           star.PMRA,
+		PMDec:          star.PMDec,
+		RadialVelocity: star.RadialVelocity,
+
+		HZInner:           star.HZInner,
+		HZOuter:           star.HZOuter,
+		HZOptimisticInner: star.HZOptimisticInner,
+		HZOptimisticOuter: star.HZOptimisticOuter,
+
+		SystemID:  star.SystemID,
+		Component: star.Component,
+
+		Architecture: star.Architecture,
 	}
+}
 
-	for _, planet := range planets {
-		p := PlanetParquet{
-			ID:            planet.ID,
-			Name:          planet.Name,
-			OrbitalPeriod: planet.OrbitalPeriod,
-			SemiMajorAxis: planet.SemiMajorAxis,
-			Eccentricity:  planet.Eccentricity,
-			Mass:          planet.Mass,
-			Radius:        planet.Radius,
-			Atmosphere:    planet.Atmosphere,
-			SurfaceTemp:   planet.SurfaceTemp,
-			HasRings:      planet.HasRings,
-			HasMoons:      planet.HasMoons,
-			DiscoveryYear: planet.DiscoveryYear,
-			StarID:        planet.StarID,
-		}
-		if err := pw.Write(p); err != nil {
-			return err
-		}
+func starSystemParquet(system models.StarSystem) StarSystemParquet {
+	return StarSystemParquet{
+		ID:           system.ID,
+		Name:         system.Name,
+		Multiplicity: system.Multiplicity,
+
+		PrimaryID:    system.PrimaryID,
+		SecondaryID:  system.SecondaryID,
+		Separation:   system.Separation,
+		Period:       system.Period,
+		Eccentricity: system.Eccentricity,
+		MassRatio:    system.MassRatio,
+
+		TertiaryID:        system.TertiaryID,
+		OuterSeparation:   system.OuterSeparation,
+		OuterPeriod:       system.OuterPeriod,
+		OuterEccentricity: system.OuterEccentricity,
+		OuterMassRatio:    system.OuterMassRatio,
 	}
+}
 
-	if err := pw.WriteStop(); err != nil {
-		return err
+func starIdentifierParquet(id models.StarIdentifier) StarIdentifierParquet {
+	return StarIdentifierParquet{
+		StarID:     id.StarID,
+		Catalog:    id.Catalog,
+		Identifier: id.Identifier,
 	}
+}
+
+func planetParquet(planet models.Planet) PlanetParquet {
+	return PlanetParquet{
+		ID:            planet.ID,
+		Name:          planet.Name,
+		OrbitalPeriod: planet.OrbitalPeriod,
+		SemiMajorAxis: planet.SemiMajorAxis,
+		Eccentricity:  planet.Eccentricity,
+
+		Inclination:              planet.Inclination,
+		LongitudeOfAscendingNode: planet.LongitudeOfAscendingNode,
+		ArgumentOfPeriapsis:      planet.ArgumentOfPeriapsis,
+		MeanAnomaly:              planet.MeanAnomaly,
+		Epoch:                    planet.Epoch,
 
-	return nil
+		Insolation:      planet.Insolation,
+		BondAlbedo:      planet.BondAlbedo,
+		EquilibriumTemp: planet.EquilibriumTemp,
+		InHabitableZone: planet.InHabitableZone,
+
+		Mass:          planet.Mass,
+		Radius:        planet.Radius,
+		PlanetClass:   planet.PlanetClass,
+		Atmosphere:    planet.Atmosphere,
+		Composition:   compositionParquet(planet.Composition),
+		SurfaceTemp:   planet.SurfaceTemp,
+		HasRings:      planet.HasRings,
+		HasMoons:      planet.HasMoons,
+		DiscoveryYear: planet.DiscoveryYear,
+		OrbitType:     planet.OrbitType,
+		StarID:        planet.StarID,
+	}
 }
 
-func writeExoplanetsParquet(exoplanets []models.Exoplanet, filename string) error {
-	fw, err := local.NewLocalFileWriter(filename)
-	if err != nil {
-		return err
+func compositionParquet(composition map[string]float64) []GasParquet {
+	if len(composition) == 0 {
+		return nil
 	}
-	defer func() {
-		if cerr := fw.Close(); cerr != nil && err == nil {
-			err = cerr
-		}
-	}()
+	gases := make([]GasParquet, 0, len(composition))
+	for _, molecule := range moleculesByAbundance(composition) {
+		gases = append(gases, GasParquet{Molecule: molecule, Fraction: composition[molecule]})
+	}
+	return gases
+}
 
-	pw, err := writer.NewParquetWriter(fw, new(ExoplanetParquet), 4)
-	if err != nil {
-		return err
+func moonParquet(moon models.Moon) MoonParquet {
+	return MoonParquet{
+		ID:            moon.ID,
+		Name:          moon.Name,
+		OrbitalRadius: moon.OrbitalRadius,
+		OrbitalPeriod: moon.OrbitalPeriod,
+		Mass:          moon.Mass,
+		Radius:        moon.Radius,
+		TidallyLocked: moon.TidallyLocked,
+		PlanetID:      moon.PlanetID,
 	}
+}
 
-	for _, exo := range exoplanets {
-		e := ExoplanetParquet{
-			ID:              exo.ID,
-			Name:            exo.Name,
-			OrbitalPeriod:   exo.OrbitalPeriod,
-			SemiMajorAxis:   exo.SemiMajorAxis,
-			Eccentricity:    exo.Eccentricity,
-			Mass:            exo.Mass,
-			Radius:          exo.Radius,
-			DetectionMethod: exo.DetectionMethod,
-			HostDistance:    exo.HostDistance,
-			SurfaceTemp:     exo.SurfaceTemp,
-			DiscoveryYear:   exo.DiscoveryYear,
-			StarID:          exo.StarID,
-		}
-		if err := pw.Write(e); err != nil {
-			return err
-		}
+func exoplanetParquet(exo models.Exoplanet) ExoplanetParquet {
+	return ExoplanetParquet{
+		ID:            exo.ID,
+		Name:          exo.Name,
+		OrbitalPeriod: exo.OrbitalPeriod,
+		SemiMajorAxis: exo.SemiMajorAxis,
+		Eccentricity:  exo.Eccentricity,
+
+		Inclination:              exo.Inclination,
+		LongitudeOfAscendingNode: exo.LongitudeOfAscendingNode,
+		ArgumentOfPeriapsis:      exo.ArgumentOfPeriapsis,
+		MeanAnomaly:              exo.MeanAnomaly,
+		Epoch:                    exo.Epoch,
+
+		Insolation:      exo.Insolation,
+		BondAlbedo:      exo.BondAlbedo,
+		EquilibriumTemp: exo.EquilibriumTemp,
+		InHabitableZone: exo.InHabitableZone,
+
+		Mass:            exo.Mass,
+		Radius:          exo.Radius,
+		PlanetClass:     exo.PlanetClass,
+		DetectionMethod: exo.DetectionMethod,
+		HostDistance:    exo.HostDistance,
+		SurfaceTemp:     exo.SurfaceTemp,
+		DiscoveryYear:   exo.DiscoveryYear,
+		Detected:        exo.Detected,
+		OrbitType:       exo.OrbitType,
+		StarID:          exo.StarID,
 	}
+}
 
-	if err := pw.WriteStop(); err != nil {
-		return err
+func lightCurveParquet(point models.LightCurvePoint) LightCurveParquet {
+	return LightCurveParquet{
+		ExoplanetID: point.ExoplanetID,
+		Time:        point.Time,
+		Flux:        point.Flux,
+		FluxError:   point.FluxError,
+		InTransit:   point.InTransit,
 	}
+}
 
-	return nil
+func rvObservationParquet(obs models.RVObservation) RVObservationParquet {
+	return RVObservationParquet{
+		ExoplanetID:    obs.ExoplanetID,
+		StarID:         obs.StarID,
+		Time:           obs.Time,
+		RadialVelocity: obs.RadialVelocity,
+		Error:          obs.Error,
+		Signal:         obs.Signal,
+	}
+}
+
+func ephemerisParquet(point models.EphemerisPoint) EphemerisParquet {
+	return EphemerisParquet{
+		PlanetID:  point.PlanetID,
+		Timestamp: point.Timestamp.UnixMilli(),
+		X:         point.X,
+		Y:         point.Y,
+		Z:         point.Z,
+		VX:        point.VX,
+		VY:        point.VY,
+		VZ:        point.VZ,
+	}
 }
@@ -0,0 +1,14 @@
+package writers
+
+import (
+	"fmt"
+	"io"
+)
+
+// closeFile closes an output file, recording the close error in err
+// unless an earlier error has already been recorded
+func closeFile(c io.Closer, what string, err *error) {
+	if cerr := c.Close(); cerr != nil && *err == nil {
+		*err = fmt.Errorf("failed to write %s: %w", what, cerr)
+	}
+}